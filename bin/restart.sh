@@ -15,5 +15,5 @@ echo ""
 sleep 2
 
 # 启动服务
-"$SCRIPT_DIR/start.sh"
+"$SCRIPT_DIR/start.sh" "$@"
 
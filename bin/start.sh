@@ -1,6 +1,7 @@
 #!/bin/bash
 
 # IPTV服务启动脚本
+# 参数会传给程序，例如 ./bin/start.sh serve 以HTTP服务模式启动
 
 # 获取脚本所在目录的绝对路径
 SCRIPT_DIR="$(cd "$(dirname "${BASH_SOURCE[0]}")" && pwd)"
@@ -36,7 +37,7 @@ mkdir -p "$LOG_DIR"
 
 # 启动程序（后台运行）
 echo "正在启动IPTV服务..."
-nohup "$PROJECT_DIR/$BINARY_NAME" > /dev/null 2>&1 &
+nohup "$PROJECT_DIR/$BINARY_NAME" "$@" > /dev/null 2>&1 &
 PID=$!
 
 # 保存PID到文件
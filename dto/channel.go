@@ -2,13 +2,34 @@ package dto
 
 import (
 	"fmt"
+	"strconv"
 	"strings"
 )
 
 // Channel 频道信息
 type Channel struct {
-	Name string
-	URL  string
+	Name     string
+	URL      string
+	RawName  string     // 抓取到的原始名称（规范化前）
+	Group    string     // 分组，例如 央视、卫视
+	Stream   StreamInfo // 探测结果
+	Priority int        // 来源优先级，健康度相同时优先级高的源排在前面
+	Page     int        // 来源页码（分页抓取时），0表示未分页
+	Kind     string     // 源类型，例如 multicast、hotel
+
+	// M3U扩展属性
+	TvgID         string // EPG频道ID
+	TvgName       string // EPG频道名称
+	Logo          string // 台标地址
+	ChNo          int    // 频道号，0表示不输出
+	Catchup       string // 回看类型，例如 append、default
+	CatchupSource string // 回看地址模板
+}
+
+// M3UOptions M3U输出选项
+type M3UOptions struct {
+	TvgURL     string // EPG地址，写入#EXTM3U x-tvg-url
+	Alternates string // 备用源写法：first 只写最优源（默认），all 每个源一条
 }
 
 // IsValidURL 检查字符串是否是有效的URL
@@ -22,31 +43,143 @@ func IsValidURL(s string) bool {
 }
 
 // ConvertToM3U 转换为M3U格式
-func ConvertToM3U(channels []Channel) string {
+func ConvertToM3U(entries []Entry, opts M3UOptions) string {
 	var builder strings.Builder
 
 	// M3U文件头
-	builder.WriteString("#EXTM3U\n")
+	if opts.TvgURL != "" {
+		builder.WriteString(fmt.Sprintf("#EXTM3U x-tvg-url=\"%s\"\n", escapeAttr(opts.TvgURL)))
+	} else {
+		builder.WriteString("#EXTM3U\n")
+	}
+
+	// 添加每个频道，默认只写最优源，all时每个源写一条同名记录
+	for _, entry := range entries {
+		sources := entry.Sources
+		if opts.Alternates != AlternatesAll && len(sources) > 1 {
+			sources = sources[:1]
+		}
+		for _, source := range sources {
+			// EXTINF格式: #EXTINF:-1 tvg-id="..." tvg-name="..." group-title="...",Channel Name
+			builder.WriteString(fmt.Sprintf("#EXTINF:-1%s,%s\n", extinfAttrs(entry.Channel), entry.Name))
+			builder.WriteString(fmt.Sprintf("%s\n", source.URL))
+		}
+	}
+
+	return builder.String()
+}
+
+// extinfAttrs 生成#EXTINF的属性部分，空值不输出
+func extinfAttrs(channel Channel) string {
+	chno := ""
+	if channel.ChNo > 0 {
+		chno = strconv.Itoa(channel.ChNo)
+	}
 
-	// 添加每个频道
-	for _, channel := range channels {
-		// EXTINF格式: #EXTINF:-1,Channel Name
-		builder.WriteString(fmt.Sprintf("#EXTINF:-1,%s\n", channel.Name))
-		builder.WriteString(fmt.Sprintf("%s\n", channel.URL))
+	attrs := []struct {
+		key   string
+		value string
+	}{
+		{"tvg-id", channel.TvgID},
+		{"tvg-name", channel.TvgName},
+		{"tvg-logo", channel.Logo},
+		{"group-title", channel.Group},
+		{"tvg-chno", chno},
+		{"catchup", channel.Catchup},
+		{"catchup-source", channel.CatchupSource},
 	}
 
+	var builder strings.Builder
+	for _, attr := range attrs {
+		if attr.value != "" {
+			builder.WriteString(fmt.Sprintf(" %s=\"%s\"", attr.key, escapeAttr(attr.value)))
+		}
+	}
 	return builder.String()
 }
 
+// escapeAttr 属性值中不能出现双引号
+func escapeAttr(value string) string {
+	return strings.ReplaceAll(value, `"`, "'")
+}
+
 // ConvertToCSV 转换为CSV格式：频道名称,接口地址
-func ConvertToCSV(channels []Channel) string {
+// 频道带有分组时按分组输出，每组前加一行 分组名,#genre#（分组按首次出现的顺序排列）
+// alternates 控制多个源的写法：lines 每个源一行（默认），join 用#连接，first 只写最优源
+func ConvertToCSV(entries []Entry, alternates string) string {
 	var builder strings.Builder
 
-	// 添加每个频道，格式：频道名称,接口地址
-	for _, channel := range channels {
-		builder.WriteString(fmt.Sprintf("%s,%s\n", channel.Name, channel.URL))
+	for _, section := range groupSections(entries) {
+		if section.group != "" {
+			builder.WriteString(fmt.Sprintf("%s,#genre#\n", section.group))
+		}
+
+		// 添加每个频道，格式：频道名称,接口地址
+		for _, entry := range section.entries {
+			switch alternates {
+			case AlternatesFirst:
+				builder.WriteString(fmt.Sprintf("%s,%s\n", entry.Name, entry.Sources[0].URL))
+			case AlternatesJoin:
+				builder.WriteString(fmt.Sprintf("%s,%s\n", entry.Name, joinURLs(entry.Sources, 0)))
+			default:
+				for _, source := range entry.Sources {
+					builder.WriteString(fmt.Sprintf("%s,%s\n", entry.Name, source.URL))
+				}
+			}
+		}
 	}
 
 	return builder.String()
 }
 
+// ConvertToDIYP 转换为DIYP/TVBox格式：按分组输出 分组名,#genre# 标题行，
+// 每个频道一行，多个源用#连接：频道名称,源1#源2#源3
+// maxSources 为每个频道最多列出的源数量，0表示不限制
+func ConvertToDIYP(entries []Entry, maxSources int) string {
+	var builder strings.Builder
+
+	for _, section := range groupSections(entries) {
+		if section.group != "" {
+			builder.WriteString(fmt.Sprintf("%s,#genre#\n", section.group))
+		}
+		for _, entry := range section.entries {
+			builder.WriteString(fmt.Sprintf("%s,%s\n", entry.Name, joinURLs(entry.Sources, maxSources)))
+		}
+	}
+
+	return builder.String()
+}
+
+// section 按分组划分的输出段
+type section struct {
+	group   string
+	entries []Entry
+}
+
+// groupSections 按分组首次出现的顺序划分条目
+func groupSections(entries []Entry) []section {
+	var sections []section
+	index := make(map[string]int)
+	for _, entry := range entries {
+		i, ok := index[entry.Group]
+		if !ok {
+			i = len(sections)
+			index[entry.Group] = i
+			sections = append(sections, section{group: entry.Group})
+		}
+		sections[i].entries = append(sections[i].entries, entry)
+	}
+	return sections
+}
+
+// joinURLs 用#连接多个源，limit为0时不限制数量
+func joinURLs(sources []Channel, limit int) string {
+	if limit > 0 && len(sources) > limit {
+		sources = sources[:limit]
+	}
+	urls := make([]string, len(sources))
+	for i, source := range sources {
+		urls[i] = source.URL
+	}
+	return strings.Join(urls, "#")
+}
@@ -0,0 +1,77 @@
+package dto
+
+import "testing"
+
+func TestConvertToM3U(t *testing.T) {
+	entries := []Entry{
+		{
+			Channel: Channel{
+				Name:          "CCTV1",
+				TvgID:         "CCTV1",
+				TvgName:       "CCTV1",
+				Logo:          "https://logo.example.com/CCTV1.png",
+				Group:         "央视",
+				ChNo:          1,
+				Catchup:       "append",
+				CatchupSource: "?playseek=${(b)yyyyMMddHHmmss}-${(e)yyyyMMddHHmmss}",
+			},
+			Sources: []Channel{{URL: "http://a/1"}, {URL: "http://a/2"}},
+		},
+		{
+			// 空属性不输出，属性值中的双引号替换为单引号
+			Channel: Channel{Name: "凤凰中文", Group: `港澳"台"`},
+			Sources: []Channel{{URL: "http://b/1"}},
+		},
+	}
+
+	want := `#EXTM3U x-tvg-url="https://epg.example.com/e.xml?a='1'"
+#EXTINF:-1 tvg-id="CCTV1" tvg-name="CCTV1" tvg-logo="https://logo.example.com/CCTV1.png" group-title="央视" tvg-chno="1" catchup="append" catchup-source="?playseek=${(b)yyyyMMddHHmmss}-${(e)yyyyMMddHHmmss}",CCTV1
+http://a/1
+#EXTINF:-1 group-title="港澳'台'",凤凰中文
+http://b/1
+`
+	got := ConvertToM3U(entries, M3UOptions{TvgURL: `https://epg.example.com/e.xml?a="1"`})
+	if got != want {
+		t.Errorf("ConvertToM3U =\n%s\nwant\n%s", got, want)
+	}
+
+	// 未配置EPG地址时只输出#EXTM3U，all时每个源一条同名记录
+	want = `#EXTM3U
+#EXTINF:-1,CCTV2
+http://c/1
+#EXTINF:-1,CCTV2
+http://c/2
+`
+	got = ConvertToM3U([]Entry{{
+		Channel: Channel{Name: "CCTV2"},
+		Sources: []Channel{{URL: "http://c/1"}, {URL: "http://c/2"}},
+	}}, M3UOptions{Alternates: AlternatesAll})
+	if got != want {
+		t.Errorf("ConvertToM3U(all) =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestConvertToDIYP(t *testing.T) {
+	entries := []Entry{
+		{Channel: Channel{Name: "CCTV1", Group: "央视"}, Sources: []Channel{{URL: "http://a/1"}, {URL: "http://a/2"}, {URL: "http://a/3"}}},
+		{Channel: Channel{Name: "湖南卫视", Group: "卫视"}, Sources: []Channel{{URL: "http://b/1"}}},
+		{Channel: Channel{Name: "CCTV2", Group: "央视"}, Sources: []Channel{{URL: "http://c/1"}, {URL: "http://c/2"}}},
+	}
+
+	tests := []struct {
+		maxSources int
+		want       string
+	}{
+		{0, "央视,#genre#\nCCTV1,http://a/1#http://a/2#http://a/3\nCCTV2,http://c/1#http://c/2\n" +
+			"卫视,#genre#\n湖南卫视,http://b/1\n"},
+		{2, "央视,#genre#\nCCTV1,http://a/1#http://a/2\nCCTV2,http://c/1#http://c/2\n" +
+			"卫视,#genre#\n湖南卫视,http://b/1\n"},
+		{1, "央视,#genre#\nCCTV1,http://a/1\nCCTV2,http://c/1\n" +
+			"卫视,#genre#\n湖南卫视,http://b/1\n"},
+	}
+	for _, tt := range tests {
+		if got := ConvertToDIYP(entries, tt.maxSources); got != tt.want {
+			t.Errorf("ConvertToDIYP(%d) =\n%s\nwant\n%s", tt.maxSources, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package dto
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// 多源频道的备用源输出方式
+const (
+	AlternatesFirst = "first" // 只输出最优源
+	AlternatesAll   = "all"   // M3U：每个源输出一条同名记录
+	AlternatesLines = "lines" // TXT：每个源一行，同名频道相邻
+	AlternatesJoin  = "join"  // TXT：多个源用#连接在同一行
+)
+
+// Entry 输出条目：一个频道及其按质量/健康度排序的多个源
+type Entry struct {
+	Channel           // 频道元数据，取自排序后的第一个源
+	Sources []Channel // 所有源，最优的在前
+}
+
+// GroupByName 按频道名称合并为多源条目，条目保持频道首次出现的顺序
+func GroupByName(channels []Channel) []Entry {
+	var entries []Entry
+	index := make(map[string]int)
+	for _, ch := range channels {
+		i, ok := index[ch.Name]
+		if !ok {
+			i = len(entries)
+			index[ch.Name] = i
+			entries = append(entries, Entry{})
+		}
+		entries[i].Sources = append(entries[i].Sources, ch)
+	}
+
+	for i := range entries {
+		SortSources(entries[i].Sources)
+		entries[i].Channel = entries[i].Sources[0]
+	}
+	return entries
+}
+
+// SortSources 按健康度和质量排序：可用 > 未探测 > 失效，再按来源优先级、分辨率、实际速率、延迟
+func SortSources(sources []Channel) {
+	sort.SliceStable(sources, func(i, j int) bool {
+		a, b := sources[i].Stream, sources[j].Stream
+		if ra, rb := statusRank(a), statusRank(b); ra != rb {
+			return ra < rb
+		}
+		if pa, pb := sources[i].Priority, sources[j].Priority; pa != pb {
+			return pa > pb
+		}
+		if ha, hb := a.height(), b.height(); ha != hb {
+			return ha > hb
+		}
+		if a.Mbps > 0 && b.Mbps > 0 && a.Mbps != b.Mbps {
+			return a.Mbps > b.Mbps
+		}
+		if a.Latency > 0 && b.Latency > 0 {
+			return a.Latency < b.Latency
+		}
+		return false
+	})
+}
+
+// statusRank 健康度排序值，越小越优
+func statusRank(s StreamInfo) int {
+	switch {
+	case s.Status == StatusAlive:
+		return 0
+	case s.IsDead():
+		return 2
+	default:
+		return 1
+	}
+}
+
+// height 实际分辨率高度，未检测时使用HLS声明的分辨率
+func (s StreamInfo) height() int {
+	if s.Height > 0 {
+		return s.Height
+	}
+	if _, h, ok := strings.Cut(strings.ToLower(s.Resolution), "x"); ok {
+		height, _ := strconv.Atoi(h)
+		return height
+	}
+	return 0
+}
+
+// SourceCount 所有条目的源总数
+func SourceCount(entries []Entry) int {
+	count := 0
+	for _, entry := range entries {
+		count += len(entry.Sources)
+	}
+	return count
+}
@@ -0,0 +1,53 @@
+package dto
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSortSources(t *testing.T) {
+	sources := []Channel{
+		{URL: "dead", Stream: StreamInfo{Status: StatusDead}, Priority: 10},
+		{URL: "timeout", Stream: StreamInfo{Status: StatusTimeout}},
+		{URL: "skipped", Stream: StreamInfo{Status: StatusSkipped}},
+		{URL: "unprobed"},
+		{URL: "720p-slow", Stream: StreamInfo{Status: StatusAlive, Resolution: "1280x720", Latency: 200 * time.Millisecond}},
+		{URL: "720p-fast", Stream: StreamInfo{Status: StatusAlive, Height: 720, Latency: 20 * time.Millisecond}},
+		{URL: "1080p-slow", Stream: StreamInfo{Status: StatusAlive, Height: 1080, Mbps: 2, Latency: 100 * time.Millisecond}},
+		{URL: "1080p-fast", Stream: StreamInfo{Status: StatusAlive, Height: 1080, Mbps: 8, Latency: 300 * time.Millisecond}},
+		{URL: "1080p-declared", Stream: StreamInfo{Status: StatusAlive, Resolution: "1920x1080", Mbps: 5}},
+		{URL: "priority", Stream: StreamInfo{Status: StatusAlive, Height: 576}, Priority: 5},
+	}
+	SortSources(sources)
+
+	// 可用 > 未探测 > 失效；同为可用时按优先级、分辨率、速率、延迟；其余保持原顺序
+	want := "priority 1080p-fast 1080p-declared 1080p-slow 720p-fast 720p-slow skipped unprobed dead timeout"
+	urls := make([]string, len(sources))
+	for i, source := range sources {
+		urls[i] = source.URL
+	}
+	if got := strings.Join(urls, " "); got != want {
+		t.Errorf("SortSources = %s\nwant %s", got, want)
+	}
+}
+
+func TestGroupByName(t *testing.T) {
+	channels := []Channel{
+		{Name: "CCTV1", URL: "http://a/1", Stream: StreamInfo{Status: StatusDead}},
+		{Name: "CCTV2", URL: "http://b/1", Stream: StreamInfo{Status: StatusAlive}},
+		{Name: "CCTV1", URL: "http://a/2", Stream: StreamInfo{Status: StatusAlive}, Group: "央视"},
+	}
+	entries := GroupByName(channels)
+
+	if len(entries) != 2 || entries[0].Name != "CCTV1" || entries[1].Name != "CCTV2" {
+		t.Fatalf("GroupByName = %+v", entries)
+	}
+	// 元数据取自最优的源
+	if entries[0].URL != "http://a/2" || entries[0].Group != "央视" || len(entries[0].Sources) != 2 {
+		t.Errorf("CCTV1 = %+v", entries[0])
+	}
+	if SourceCount(entries) != 3 {
+		t.Errorf("SourceCount = %d, want 3", SourceCount(entries))
+	}
+}
@@ -0,0 +1,57 @@
+package dto
+
+// 源类型
+const (
+	KindMulticast = "multicast" // 组播源（udpxy转发的运营商组播）
+	KindHotel     = "hotel"     // 酒店源
+	KindSearch    = "search"    // 关键字搜索结果
+	KindImport    = "import"    // 导入的播放列表
+)
+
+// KindLabel 源类型的显示名称，未知类型原样返回
+func KindLabel(kind string) string {
+	switch kind {
+	case KindMulticast:
+		return "组播"
+	case KindHotel:
+		return "酒店"
+	case KindSearch:
+		return "搜索"
+	case KindImport:
+		return "导入"
+	default:
+		return kind
+	}
+}
+
+// FilterKinds 只保留指定类型的源，kinds为空时不过滤
+func FilterKinds(channels []Channel, kinds []string) []Channel {
+	if len(kinds) == 0 {
+		return channels
+	}
+
+	allowed := make(map[string]bool, len(kinds))
+	for _, kind := range kinds {
+		allowed[kind] = true
+	}
+
+	var filtered []Channel
+	for _, ch := range channels {
+		if allowed[ch.Kind] {
+			filtered = append(filtered, ch)
+		}
+	}
+	return filtered
+}
+
+// SplitKinds 按源类型拆分，类型按首次出现的顺序排列
+func SplitKinds(channels []Channel) (kinds []string, byKind map[string][]Channel) {
+	byKind = make(map[string][]Channel)
+	for _, ch := range channels {
+		if _, ok := byKind[ch.Kind]; !ok {
+			kinds = append(kinds, ch.Kind)
+		}
+		byKind[ch.Kind] = append(byKind[ch.Kind], ch)
+	}
+	return kinds, byKind
+}
@@ -0,0 +1,31 @@
+package dto
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestKinds(t *testing.T) {
+	channels := []Channel{
+		{Name: "CCTV1", URL: "http://a/1", Kind: KindHotel},
+		{Name: "CCTV1", URL: "http://a/2", Kind: KindMulticast},
+		{Name: "CCTV2", URL: "http://a/3", Kind: KindHotel},
+		{Name: "CCTV5+", URL: "http://a/4", Kind: KindSearch},
+	}
+
+	filtered := FilterKinds(channels, []string{KindHotel, KindSearch})
+	if len(filtered) != 3 || filtered[1].URL != "http://a/3" {
+		t.Errorf("FilterKinds = %+v", filtered)
+	}
+	if got := FilterKinds(channels, nil); len(got) != len(channels) {
+		t.Errorf("FilterKinds(nil) = %d channels", len(got))
+	}
+
+	kinds, byKind := SplitKinds(channels)
+	if !reflect.DeepEqual(kinds, []string{KindHotel, KindMulticast, KindSearch}) {
+		t.Errorf("SplitKinds kinds = %v", kinds)
+	}
+	if len(byKind[KindHotel]) != 2 {
+		t.Errorf("hotel channels = %d, want 2", len(byKind[KindHotel]))
+	}
+}
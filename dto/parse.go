@@ -0,0 +1,191 @@
+package dto
+
+import (
+	"bufio"
+	"strconv"
+	"strings"
+)
+
+// ParseM3U 解析M3U/M3U8播放列表，读取#EXTINF中的扩展属性和#EXTGRP分组
+// 没有#EXTINF的地址行和不是有效地址的行会被跳过
+func ParseM3U(content string) []Channel {
+	var channels []Channel
+	var current *Channel
+	group := "" // #EXTGRP 指定的分组，只作用于下一个频道
+
+	scanner := bufio.NewScanner(strings.NewReader(strings.TrimPrefix(content, "\ufeff")))
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case line == "":
+			continue
+		case strings.HasPrefix(line, "#EXTINF:"):
+			ch := parseExtinf(strings.TrimPrefix(line, "#EXTINF:"))
+			current = &ch
+		case strings.HasPrefix(line, "#EXTGRP:"):
+			group = strings.TrimSpace(strings.TrimPrefix(line, "#EXTGRP:"))
+		case strings.HasPrefix(line, "#"):
+			// #EXTM3U、#EXTVLCOPT 等其他标签
+			continue
+		default:
+			if current == nil || !IsValidURL(line) {
+				continue
+			}
+			if current.Group == "" {
+				current.Group = group
+			}
+			current.URL = line
+			channels = append(channels, *current)
+			current = nil
+			group = ""
+		}
+	}
+
+	return channels
+}
+
+// parseExtinf 解析 -1 tvg-id="..." group-title="...",频道名称
+// 属性值中可能包含逗号，名称从引号外的第一个逗号之后开始
+func parseExtinf(info string) Channel {
+	attrPart, name := info, ""
+	inQuote := false
+	for i, r := range info {
+		if r == '"' {
+			inQuote = !inQuote
+		} else if r == ',' && !inQuote {
+			attrPart, name = info[:i], info[i+1:]
+			break
+		}
+	}
+
+	ch := Channel{Name: strings.TrimSpace(name)}
+	for key, value := range parseAttrs(attrPart) {
+		switch strings.ToLower(key) {
+		case "tvg-id":
+			ch.TvgID = value
+		case "tvg-name":
+			ch.TvgName = value
+		case "tvg-logo":
+			ch.Logo = value
+		case "group-title":
+			ch.Group = value
+		case "tvg-chno":
+			ch.ChNo, _ = strconv.Atoi(value)
+		case "catchup":
+			ch.Catchup = value
+		case "catchup-source":
+			ch.CatchupSource = value
+		}
+	}
+
+	if ch.Name == "" {
+		ch.Name = ch.TvgName
+	}
+	return ch
+}
+
+// parseAttrs 解析 key="value" 形式的属性，值可以不带引号
+func parseAttrs(s string) map[string]string {
+	attrs := make(map[string]string)
+	for {
+		eq := strings.IndexByte(s, '=')
+		if eq < 0 {
+			return attrs
+		}
+		fields := strings.Fields(s[:eq])
+		if len(fields) == 0 {
+			return attrs
+		}
+		key := fields[len(fields)-1]
+		s = s[eq+1:]
+
+		var value string
+		if strings.HasPrefix(s, `"`) {
+			end := strings.IndexByte(s[1:], '"')
+			if end < 0 {
+				value, s = s[1:], ""
+			} else {
+				value, s = s[1:end+1], s[end+2:]
+			}
+		} else {
+			end := strings.IndexAny(s, " \t")
+			if end < 0 {
+				value, s = s, ""
+			} else {
+				value, s = s[:end], s[end:]
+			}
+		}
+		attrs[key] = strings.TrimSpace(value)
+	}
+}
+
+// ParseTXT 解析DIYP/TXT频道列表（ConvertToCSV/ConvertToDIYP的逆过程）：
+// 分组名,#genre# 为分组标题行，频道名称,地址 为频道行，多个地址用#连接，地址后的 $备注 会被去掉
+func ParseTXT(content string) []Channel {
+	var channels []Channel
+	group := ""
+
+	scanner := bufio.NewScanner(strings.NewReader(strings.TrimPrefix(content, "\ufeff")))
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		name, urls, ok := strings.Cut(line, ",")
+		if !ok || strings.HasPrefix(line, "#") || strings.HasPrefix(line, "//") {
+			continue
+		}
+		name, urls = strings.TrimSpace(name), strings.TrimSpace(urls)
+
+		if urls == "#genre#" {
+			group = name
+			continue
+		}
+		if name == "" {
+			continue
+		}
+
+		for _, u := range splitSources(urls) {
+			if IsValidURL(u) {
+				channels = append(channels, Channel{Name: name, URL: u, Group: group})
+			}
+		}
+	}
+
+	return channels
+}
+
+// splitSources 拆分用#连接的多个地址，只在#后面是新地址时拆分（地址本身可能包含#）
+func splitSources(urls string) []string {
+	var sources []string
+	rest := urls
+	for {
+		i := 0
+		for {
+			next := strings.IndexByte(rest[i:], '#')
+			if next < 0 {
+				i = -1
+				break
+			}
+			i += next
+			if IsValidURL(rest[i+1:]) {
+				break
+			}
+			i++
+		}
+
+		source := rest
+		if i >= 0 {
+			source = rest[:i]
+		}
+		// 去掉 $备注（例如 $高清、$IPV6）
+		if j := strings.LastIndexByte(source, '$'); j > 0 {
+			source = source[:j]
+		}
+		sources = append(sources, strings.TrimSpace(source))
+
+		if i < 0 {
+			return sources
+		}
+		rest = rest[i+1:]
+	}
+}
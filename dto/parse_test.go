@@ -0,0 +1,64 @@
+package dto
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseM3U(t *testing.T) {
+	content := "\ufeff#EXTM3U x-tvg-url=\"https://epg.example.com/e.xml\"\r\n" +
+		"#EXTINF:-1 tvg-id=\"CCTV1\" tvg-name=\"CCTV1\" tvg-logo=\"https://logo.example.com/CCTV1.png\" group-title=\"央视,高清\" tvg-chno=\"1\" catchup=\"append\",CCTV-1 综合\r\n" +
+		"#EXTVLCOPT:http-user-agent=VLC\r\n" +
+		"http://example.com/cctv1.m3u8\r\n" +
+		"#EXTINF:-1,湖南卫视\n" +
+		"#EXTGRP:卫视\n" +
+		"rtp://239.3.1.241:8000\n" +
+		"http://example.com/orphan.m3u8\n" +
+		"#EXTINF:-1 tvg-name=凤凰中文,\n" +
+		"not-a-url\n" +
+		"#EXTINF:-1 tvg-name=凤凰中文,\n" +
+		"http://example.com/phoenix.m3u8\n"
+
+	want := []Channel{
+		{
+			Name: "CCTV-1 综合", URL: "http://example.com/cctv1.m3u8", Group: "央视,高清",
+			TvgID: "CCTV1", TvgName: "CCTV1", Logo: "https://logo.example.com/CCTV1.png", ChNo: 1, Catchup: "append",
+		},
+		{Name: "湖南卫视", URL: "rtp://239.3.1.241:8000", Group: "卫视"},
+		{Name: "凤凰中文", URL: "http://example.com/phoenix.m3u8", TvgName: "凤凰中文"},
+	}
+
+	got := ParseM3U(content)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseM3U =\n%+v\nwant\n%+v", got, want)
+	}
+}
+
+func TestParseTXT(t *testing.T) {
+	content := "央视,#genre#\n" +
+		"CCTV1,http://example.com/cctv1.m3u8#http://example.com/cctv1b.m3u8$高清\n" +
+		"CCTV2,http://example.com/live#anchor\n" +
+		"\n" +
+		"卫视,#genre#\n" +
+		"湖南卫视,rtp://239.3.1.241:8000\n" +
+		"无效,not-a-url\n" +
+		"没有逗号\n"
+
+	want := []Channel{
+		{Name: "CCTV1", URL: "http://example.com/cctv1.m3u8", Group: "央视"},
+		{Name: "CCTV1", URL: "http://example.com/cctv1b.m3u8", Group: "央视"},
+		{Name: "CCTV2", URL: "http://example.com/live#anchor", Group: "央视"},
+		{Name: "湖南卫视", URL: "rtp://239.3.1.241:8000", Group: "卫视"},
+	}
+
+	got := ParseTXT(content)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseTXT =\n%+v\nwant\n%+v", got, want)
+	}
+
+	// 与ConvertToCSV互为逆过程
+	entries := GroupByName(want)
+	if got := ParseTXT(ConvertToCSV(entries, AlternatesJoin)); !reflect.DeepEqual(got, want) {
+		t.Errorf("round trip =\n%+v\nwant\n%+v", got, want)
+	}
+}
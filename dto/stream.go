@@ -0,0 +1,80 @@
+package dto
+
+import (
+	"strings"
+	"time"
+)
+
+// 探测状态
+const (
+	StatusAlive   = "alive"   // 可播放
+	StatusDead    = "dead"    // 无法播放
+	StatusTimeout = "timeout" // 探测超时
+	StatusSkipped = "skipped" // 协议不支持探测（如udp/rtp组播）
+)
+
+// StreamInfo 流探测信息
+type StreamInfo struct {
+	Status  string        // 探测状态，未探测时为空
+	Latency time.Duration // 首包延迟
+	Reason  string        // 失败原因
+
+	// HLS流信息
+	Bandwidth  int     // 声明带宽（bps）
+	Resolution string  // 声明分辨率，例如 1920x1080
+	Codecs     string  // 声明编码，例如 avc1.64001f,mp4a.40.2
+	Mbps       float64 // 实际分片下载速率
+
+	// TS流检测信息
+	VideoCodec string // h264/hevc/mpeg2...
+	AudioCodec string // aac/mp2/ac3...
+	Width      int    // 实际分辨率
+	Height     int
+	FrameRate  float64
+}
+
+// IsDead 是否已确认失效（超时也视为失效）
+func (s StreamInfo) IsDead() bool {
+	return s.Status == StatusDead || s.Status == StatusTimeout
+}
+
+// 声明编码前缀对应的编码名称
+var codecTags = map[string]string{
+	"avc1": "h264",
+	"avc3": "h264",
+	"hvc1": "hevc",
+	"hev1": "hevc",
+	"mp4a": "aac",
+	"ac-3": "ac3",
+	"ec-3": "eac3",
+}
+
+// HasCodec 检测到的或HLS声明的编码中是否包含指定编码
+func (s StreamInfo) HasCodec(codec string) bool {
+	codec = strings.ToLower(codec)
+	if s.VideoCodec == codec || s.AudioCodec == codec {
+		return true
+	}
+	for _, declared := range strings.Split(strings.ToLower(s.Codecs), ",") {
+		tag, _, _ := strings.Cut(strings.TrimSpace(declared), ".")
+		if codecTags[tag] == codec {
+			return true
+		}
+	}
+	return false
+}
+
+// Quality 根据分辨率返回清晰度标签：4K/HD/SD，未知时为空
+func (s StreamInfo) Quality() string {
+	height := s.height()
+	switch {
+	case height >= 2160:
+		return "4K"
+	case height >= 720:
+		return "HD"
+	case height > 0:
+		return "SD"
+	default:
+		return ""
+	}
+}
@@ -6,12 +6,12 @@ require (
 	github.com/PuerkitoBio/goquery v1.11.0
 	github.com/go-resty/resty/v2 v2.17.1
 	github.com/robfig/cron/v3 v3.0.1
+	golang.org/x/net v0.47.0
 	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
 	github.com/andybalholm/cascadia v1.3.3 // indirect
 	github.com/kr/pretty v0.1.0 // indirect
-	golang.org/x/net v0.47.0 // indirect
 	gopkg.in/check.v1 v1.0.0-20180628173108-788fd7840127 // indirect
 )
@@ -2,9 +2,12 @@ package main
 
 import (
 	"iptv/pkg/config"
+	"iptv/pkg/cookie"
 	"iptv/pkg/cron"
 	httppkg "iptv/pkg/http"
 	"iptv/pkg/log"
+	_ "iptv/pkg/provider/playlist" // 注册内置来源
+	_ "iptv/pkg/provider/tonkiang"
 	"os"
 )
 
@@ -20,33 +23,65 @@ func main() {
 	cfg, err := config.LoadConfig("config/app.yml")
 	if err != nil {
 		log.Error("加载配置失败: %v", err)
-		os.Exit(1)
+		exit(1)
 	}
 
 	// 初始化HTTP客户端
 	err = httppkg.Init()
 	if err != nil {
 		log.Error("初始化HTTP客户端失败: %v", err)
-		os.Exit(1)
+		exit(1)
+	}
+
+	// 初始化Cookie（配置了cookie文件时监听文件变化）
+	err = cookie.Init()
+	if err != nil {
+		log.Error("初始化Cookie失败: %v", err)
+		exit(1)
+	}
+
+	// serve模式：启动内置HTTP服务，提供最新生成的播放列表
+	serveMode := len(os.Args) > 1 && os.Args[1] == "serve"
+	if serveMode {
+		err = startServer(cfg)
+		if err != nil {
+			log.Error("启动HTTP服务失败: %v", err)
+			exit(1)
+		}
 	}
 
-	// 执行主任务
-	runTask(cfg)
+	// 执行主任务（serve模式下任务失败不退出，继续提供上一次的结果）
+	task := func() {
+		err := runTask(cfg)
+		if err != nil && !serveMode {
+			exit(1)
+		}
+	}
+	task()
 
 	// 如果启用定时任务，启动调度器
 	if cfg.Crontab.Enable {
 		log.Info("定时任务已启用: %s", cfg.Crontab.Job)
 		cron.Init()
-		err := cron.AddJob(cfg.Crontab.Job, func() {
-			runTask(cfg)
-		})
+		err := cron.AddJob(cfg.Crontab.Job, task)
 		if err != nil {
 			log.Error("添加定时任务失败: %v", err)
-			return
+			if !serveMode {
+				return
+			}
+		} else {
+			cron.Start()
 		}
-		cron.Start()
+	}
 
-		// 保持程序运行
+	// 保持程序运行
+	if cfg.Crontab.Enable || serveMode {
 		select {}
 	}
 }
+
+// exit 等待ERROR日志推送完成后退出（os.Exit不会执行defer）
+func exit(code int) {
+	log.Close()
+	os.Exit(code)
+}
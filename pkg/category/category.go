@@ -0,0 +1,137 @@
+package category
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"iptv/pkg/config"
+)
+
+// DefaultFallback 未匹配任何规则时的分组
+const DefaultFallback = "其他"
+
+// defaultOrder 默认的分组展示顺序
+var defaultOrder = []string{"央视", "卫视", "地方", "数字付费", "港澳台", "体育", "少儿", DefaultFallback}
+
+// defaultRules 默认分类规则（按匹配顺序，先匹配的优先）
+var defaultRules = []config.CategoryRule{
+	{
+		Group:    "港澳台",
+		Keywords: []string{"凤凰", "TVB", "翡翠", "明珠", "无线", "澳视", "澳门", "香港", "台视", "中视", "华视", "民视", "中天", "东森", "三立", "纬来", "八大", "星空"},
+	},
+	{
+		Group: "数字付费",
+		Keywords: []string{"CHC", "风云", "怀旧剧场", "第一剧场", "文化精品", "世界地理", "兵器科技", "女性时尚", "高尔夫", "电视指南",
+			"台球", "发现之旅", "老故事", "中学生", "求索", "NEWTV", "IHOT", "爱上4K", "精品", "剧场"},
+	},
+	{
+		Group:    "央视",
+		Patterns: []string{`^CCTV`, `^CGTN`, `^CETV`, `中国教育`},
+	},
+	{
+		Group:    "少儿",
+		Keywords: []string{"少儿", "卡通", "动漫", "动画", "卡酷", "炫动", "儿童", "宝宝"},
+	},
+	{
+		Group:    "体育",
+		Keywords: []string{"体育", "足球", "篮球", "赛事", "搏击", "五星"},
+	},
+	{
+		Group:    "卫视",
+		Keywords: []string{"卫视"},
+	},
+	{
+		Group: "地方",
+		Keywords: []string{"北京", "天津", "上海", "重庆", "河北", "山西", "辽宁", "吉林", "黑龙江", "江苏", "浙江", "安徽", "福建",
+			"江西", "山东", "河南", "湖北", "湖南", "广东", "广西", "海南", "四川", "贵州", "云南", "陕西", "甘肃", "青海",
+			"内蒙古", "宁夏", "新疆", "西藏", "珠江", "南方", "都市", "公共", "新闻综合", "综合", "经济", "生活", "影视"},
+	},
+}
+
+// compiledRule 编译后的规则
+type compiledRule struct {
+	group    string
+	keywords []string
+	patterns []*regexp.Regexp
+}
+
+// Classifier 频道分组器
+type Classifier struct {
+	rules    []compiledRule
+	order    map[string]int
+	fallback string
+}
+
+// New 创建分组器，rules为空时使用默认规则，order为空时按规则出现顺序排列分组
+func New(rules []config.CategoryRule, order []string, fallback string) (*Classifier, error) {
+	if len(rules) == 0 {
+		rules = defaultRules
+		if len(order) == 0 {
+			order = defaultOrder
+		}
+	}
+	if fallback == "" {
+		fallback = DefaultFallback
+	}
+
+	c := &Classifier{order: make(map[string]int), fallback: fallback}
+	for _, group := range order {
+		c.addOrder(group)
+	}
+
+	for _, rule := range rules {
+		if rule.Group == "" {
+			return nil, fmt.Errorf("分组规则缺少group")
+		}
+		compiled := compiledRule{group: rule.Group}
+		for _, keyword := range rule.Keywords {
+			compiled.keywords = append(compiled.keywords, strings.ToUpper(keyword))
+		}
+		for _, pattern := range rule.Patterns {
+			re, err := regexp.Compile("(?i)" + pattern)
+			if err != nil {
+				return nil, fmt.Errorf("分组【%s】的正则无效: %v", rule.Group, err)
+			}
+			compiled.patterns = append(compiled.patterns, re)
+		}
+		c.rules = append(c.rules, compiled)
+		c.addOrder(rule.Group)
+	}
+	c.addOrder(fallback)
+
+	return c, nil
+}
+
+// addOrder 记录分组的展示顺序
+func (c *Classifier) addOrder(group string) {
+	if _, ok := c.order[group]; !ok {
+		c.order[group] = len(c.order)
+	}
+}
+
+// Group 返回频道名称所属的分组
+func (c *Classifier) Group(name string) string {
+	upper := strings.ToUpper(name)
+	for _, rule := range c.rules {
+		for _, keyword := range rule.keywords {
+			if strings.Contains(upper, keyword) {
+				return rule.group
+			}
+		}
+		for _, re := range rule.patterns {
+			if re.MatchString(name) {
+				return rule.group
+			}
+		}
+	}
+	return c.fallback
+}
+
+// Order 返回分组的展示顺序，未知分组排在最后
+func (c *Classifier) Order(group string) int {
+	if index, ok := c.order[group]; ok {
+		return index
+	}
+	return len(c.order)
+}
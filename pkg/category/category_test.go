@@ -0,0 +1,102 @@
+package category
+
+import (
+	"testing"
+
+	"iptv/pkg/config"
+)
+
+func TestDefaultRules(t *testing.T) {
+	c, err := New(nil, nil, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		group string
+	}{
+		{"CCTV1", "央视"},
+		{"cctv5+", "央视"},
+		{"CGTN", "央视"},
+		{"中国教育1台", "央视"},
+		{"湖南卫视", "卫视"},
+		{"广东珠江", "地方"},
+		{"CHC动作电影", "数字付费"},
+		{"凤凰中文", "港澳台"},
+		{"五星体育", "体育"},
+		{"金鹰卡通", "少儿"},
+		// 先匹配的规则优先：卫视之前的少儿、港澳台规则
+		{"卡酷少儿", "少儿"},
+		{"凤凰卫视", "港澳台"},
+		{"CCTV风云足球", "数字付费"},
+		{"Discovery", DefaultFallback},
+		{"", DefaultFallback},
+	}
+	for _, tt := range tests {
+		if got := c.Group(tt.name); got != tt.group {
+			t.Errorf("Group(%q) = %q, want %q", tt.name, got, tt.group)
+		}
+	}
+
+	// 默认分组顺序，未知分组排在最后
+	for i, group := range defaultOrder {
+		if got := c.Order(group); got != i {
+			t.Errorf("Order(%q) = %d, want %d", group, got, i)
+		}
+	}
+	if got := c.Order("未知"); got != len(defaultOrder) {
+		t.Errorf("Order(未知) = %d, want %d", got, len(defaultOrder))
+	}
+}
+
+func TestCustomRules(t *testing.T) {
+	rules := []config.CategoryRule{
+		{Group: "体育", Keywords: []string{"体育"}, Patterns: []string{`^CCTV-?5`}},
+		{Group: "央视", Patterns: []string{`^CCTV`}},
+		{Group: "本地", Keywords: []string{"广州"}},
+	}
+	c, err := New(rules, nil, "未分组")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		group string
+	}{
+		{"CCTV5+", "体育"},
+		{"cctv-5", "体育"},
+		{"CCTV1", "央视"},
+		{"广州体育", "体育"},
+		{"广州综合", "本地"},
+		{"湖南卫视", "未分组"},
+	}
+	for _, tt := range tests {
+		if got := c.Group(tt.name); got != tt.group {
+			t.Errorf("Group(%q) = %q, want %q", tt.name, got, tt.group)
+		}
+	}
+
+	// 未配置顺序时按规则出现顺序排列，兜底分组在最后
+	for i, group := range []string{"体育", "央视", "本地", "未分组"} {
+		if got := c.Order(group); got != i {
+			t.Errorf("Order(%q) = %d, want %d", group, got, i)
+		}
+	}
+}
+
+func TestInvalidRules(t *testing.T) {
+	tests := []struct {
+		name  string
+		rules []config.CategoryRule
+	}{
+		{"missing group", []config.CategoryRule{{Keywords: []string{"体育"}}}},
+		{"invalid pattern", []config.CategoryRule{{Group: "体育", Patterns: []string{"("}}}},
+	}
+	for _, tt := range tests {
+		if _, err := New(tt.rules, nil, ""); err == nil {
+			t.Errorf("%s: New() error = nil", tt.name)
+		}
+	}
+}
@@ -15,25 +15,98 @@ type Config struct {
 		Enable bool `yaml:"enable"`
 		Limit  int  `yaml:"limit"`
 	} `yaml:"multicastIP"`
-	Cookie struct {
-		Data string `yaml:"data"`
+	Providers []ProviderConfig `yaml:"providers"`
+	Cookie    struct {
+		Data     string `yaml:"data"`
+		File     string `yaml:"file"`
+		Domain   string `yaml:"domain"`
+		Interval int    `yaml:"interval"`
+		Profile  string `yaml:"profile"`
 	} `yaml:"cookie"`
 	Crontab struct {
 		Enable bool   `yaml:"enable"`
 		Job    string `yaml:"job"`
 	} `yaml:"crontab"`
 	Output struct {
-		M3U   string `yaml:"m3u"`
-		Local string `yaml:"local"`
-		Debug string `yaml:"debug"`
+		M3U            string `yaml:"m3u"`
+		Local          string `yaml:"local"`
+		Debug          string `yaml:"debug"`
+		DIYP           string `yaml:"diyp"`
+		DIYPMaxSources int    `yaml:"diypMaxSources"`
+		QualityTag     bool   `yaml:"qualityTag"`
+		Alternates     struct {
+			M3U string `yaml:"m3u"`
+			TXT string `yaml:"txt"`
+		} `yaml:"alternates"`
+		Kinds  []string `yaml:"kinds"`
+		ByKind bool     `yaml:"byKind"`
+		Home   struct {
+			M3U   string   `yaml:"m3u"`
+			TXT   string   `yaml:"txt"`
+			Udpxy string   `yaml:"udpxy"`
+			Hosts []string `yaml:"hosts"`
+		} `yaml:"home"`
 	} `yaml:"output"`
+	M3U struct {
+		TvgURL        string `yaml:"tvgUrl"`
+		Logo          string `yaml:"logo"`
+		Catchup       string `yaml:"catchup"`
+		CatchupSource string `yaml:"catchupSource"`
+		ChannelNumber bool   `yaml:"channelNumber"`
+	} `yaml:"m3u"`
+	Server struct {
+		Listen    string `yaml:"listen"`
+		PublicURL string `yaml:"publicUrl"`
+		Proxy     struct {
+			Enable     bool `yaml:"enable"`
+			CacheSize  int  `yaml:"cacheSize"`
+			SegmentTTL int  `yaml:"segmentTTL"`
+			Play       bool `yaml:"play"`
+		} `yaml:"proxy"`
+		Relay struct {
+			Enable    bool   `yaml:"enable"`
+			Interface string `yaml:"interface"`
+		} `yaml:"relay"`
+	} `yaml:"server"`
 	Log struct {
 		Path string `yaml:"path"`
 	} `yaml:"log"`
 	HTTP struct {
-		Timeout    int `yaml:"timeout"`
-		MaxWorkers int `yaml:"maxWorkers"`
+		Timeout    int           `yaml:"timeout"`
+		MaxWorkers int           `yaml:"maxWorkers"`
+		Profile    string        `yaml:"profile"`
+		Profiles   []HTTPProfile `yaml:"profiles"`
+		Proxies    []string      `yaml:"proxies"`
+		ProxyCheck struct {
+			URL         string `yaml:"url"`
+			Interval    int    `yaml:"interval"`
+			MaxFailures int    `yaml:"maxFailures"`
+		} `yaml:"proxyCheck"`
+		Retry struct {
+			Max       *int    `yaml:"max"`
+			BaseDelay float64 `yaml:"baseDelay"`
+			MaxDelay  float64 `yaml:"maxDelay"`
+		} `yaml:"retry"`
 	} `yaml:"http"`
+	Normalize struct {
+		Enable    bool   `yaml:"enable"`
+		AliasFile string `yaml:"aliasFile"`
+	} `yaml:"normalize"`
+	Category struct {
+		Enable   bool           `yaml:"enable"`
+		Fallback string         `yaml:"fallback"`
+		Order    []string       `yaml:"order"`
+		Rules    []CategoryRule `yaml:"rules"`
+	} `yaml:"category"`
+	Probe struct {
+		Enable        bool     `yaml:"enable"`
+		Timeout       int      `yaml:"timeout"`
+		MaxWorkers    int      `yaml:"maxWorkers"`
+		Segments      int      `yaml:"segments"`
+		Inspect       bool     `yaml:"inspect"`
+		ExcludeCodecs []string `yaml:"excludeCodecs"`
+		Action        string   `yaml:"action"`
+	} `yaml:"probe"`
 	Push struct {
 		Bark struct {
 			Host string `yaml:"host"`
@@ -47,6 +120,54 @@ type Config struct {
 	} `yaml:"redirectOutput"`
 }
 
+// ProviderConfig 频道来源配置，不同类型的来源使用其中的部分字段
+type ProviderConfig struct {
+	Name string `yaml:"name"` // 名称（日志和统计），默认与类型相同
+	Type string `yaml:"type"` // 来源类型，例如 tonkiang
+
+	Profile string   `yaml:"profile"` // 请求使用的浏览器配置名称，默认使用 cookie.profile 或 http.profile
+	Proxies []string `yaml:"proxies"` // 请求使用的代理池，默认使用 http.proxies
+
+	// tonkiang
+	Discover    bool     `yaml:"discover"`    // 是否从组播源列表更新来源文件
+	Kinds       []string `yaml:"kinds"`       // 发现的源类型：multicast 组播源、hotel 酒店源，默认 [multicast]
+	Limit       int      `yaml:"limit"`       // 每种源类型获取的数量
+	SourceFile  string   `yaml:"sourceFile"`  // 来源地址文件，默认 config/source.txt
+	SkipFirst   *bool    `yaml:"skipFirst"`   // 是否跳过列表中的第一条，默认跳过
+	Province    string   `yaml:"province"`    // 只保留地区包含该名称的源，例如 广东
+	ISP         string   `yaml:"isp"`         // 只保留该运营商的源，例如 电信
+	OnlineOnly  bool     `yaml:"onlineOnly"`  // 只保留在线的源
+	MinChannels int      `yaml:"minChannels"` // 只保留频道数量不少于该值的源
+
+	// m3u/txt 导入
+	Sources     []string `yaml:"sources"`     // 文件路径或http(s)地址
+	Priority    int      `yaml:"priority"`    // 优先级，健康度相同时优先级高的源排在前面（tonkiang-search也可用）
+	GroupPrefix string   `yaml:"groupPrefix"` // 分组前缀，加在播放列表中的分组名称前
+
+	// tonkiang/tonkiang-search
+	MaxPages  int `yaml:"maxPages"`  // 每个地址或关键字最多抓取的页数
+	PageDelay int `yaml:"pageDelay"` // 翻页间隔（秒）
+
+	// tonkiang-search
+	Keywords []string `yaml:"keywords"` // 搜索的频道名称关键字
+}
+
+// HTTPProfile 浏览器请求头配置，应与获取cookie的浏览器一致（cf_clearance与User-Agent绑定）
+type HTTPProfile struct {
+	Name           string            `yaml:"name"`           // 配置名称，与内置配置同名时覆盖内置配置
+	UserAgent      string            `yaml:"userAgent"`      // User-Agent
+	AcceptLanguage string            `yaml:"acceptLanguage"` // Accept-Language
+	Headers        map[string]string `yaml:"headers"`        // 其他请求头，例如 Sec-Ch-Ua 等客户端提示
+	HeaderOrder    []string          `yaml:"headerOrder"`    // 请求头顺序，配置后使用HTTP/1.1按该顺序发送
+}
+
+// CategoryRule 频道分组规则：名称包含任一关键字或匹配任一正则即归入该分组
+type CategoryRule struct {
+	Group    string   `yaml:"group"`
+	Keywords []string `yaml:"keywords"`
+	Patterns []string `yaml:"patterns"`
+}
+
 var globalConfig *Config
 
 // LoadConfig 加载配置文件
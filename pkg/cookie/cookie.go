@@ -0,0 +1,329 @@
+package cookie
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"iptv/pkg/config"
+	"iptv/pkg/log"
+)
+
+const (
+	defaultInterval = 10 * time.Second // 默认检查cookie文件变化的间隔
+	defaultDomain   = "tonkiang.us"    // 默认只使用该域名的cookie
+)
+
+// Cookie 单个cookie
+type Cookie struct {
+	Name     string
+	Value    string
+	Domain   string
+	HostOnly bool      // 只发送给Domain本身，不发送给子域名
+	Expires  time.Time // 零值表示会话cookie
+}
+
+var (
+	mu      sync.RWMutex
+	current string    // 当前使用的Cookie请求头
+	cookies []Cookie  // 当前文件中的cookie
+	modTime time.Time // 上次加载的文件修改时间
+	size    int64     // 上次加载的文件大小
+)
+
+// Init 初始化cookie：配置了cookie文件时从文件加载并定时检查变化，否则使用配置中的cookie字符串
+func Init() error {
+	cfg := config.GetConfig()
+	if cfg == nil {
+		return fmt.Errorf("配置未加载")
+	}
+
+	mu.Lock()
+	current = cfg.Cookie.Data
+	mu.Unlock()
+
+	if cfg.Cookie.File == "" {
+		return nil
+	}
+
+	interval := defaultInterval
+	if cfg.Cookie.Interval > 0 {
+		interval = time.Duration(cfg.Cookie.Interval) * time.Second
+	}
+
+	domain := cfg.Cookie.Domain
+	if domain == "" {
+		domain = defaultDomain
+	}
+
+	err := reload(cfg.Cookie.File, domain)
+	if err != nil {
+		log.Warn("加载cookie文件失败，暂时使用配置中的cookie: %v", err)
+	}
+	go watch(cfg.Cookie.File, domain, interval)
+	return nil
+}
+
+// Get 当前的Cookie请求头
+func Get() string {
+	mu.RLock()
+	defer mu.RUnlock()
+	return current
+}
+
+// watch 定时检查文件的修改时间和大小，变化时重新加载
+func watch(path, domain string, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	lastErr := "" // 文件未变化时同样的错误只记录一次
+	for range ticker.C {
+		info, err := os.Stat(path)
+		if err != nil {
+			continue
+		}
+
+		mu.RLock()
+		changed := !info.ModTime().Equal(modTime) || info.Size() != size
+		mu.RUnlock()
+		if !changed {
+			continue
+		}
+
+		// 加载失败时不记录修改时间，文件可能还没写完，下次检查时重试
+		err = reload(path, domain)
+		if err == nil {
+			lastErr = ""
+			continue
+		}
+		if err.Error() != lastErr {
+			lastErr = err.Error()
+			log.Warn("重新加载cookie文件失败，继续使用原有cookie: %v", err)
+		}
+	}
+}
+
+// reload 加载cookie文件，cookie有变化时替换当前cookie并记录日志，成功后才记录文件的修改时间和大小
+func reload(path, domain string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	loaded, err := Parse(data)
+	if err != nil {
+		return err
+	}
+	loaded = filter(loaded, domain, time.Now())
+	if len(loaded) == 0 {
+		return fmt.Errorf("%s中没有%s的有效cookie", path, domain)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if diff := diffNames(cookies, loaded); diff != "" {
+		log.Info("Cookie已更新（%s）: %s", path, diff)
+	}
+	cookies = loaded
+	current = Header(loaded)
+	modTime, size = info.ModTime(), info.Size()
+	return nil
+}
+
+// Parse 解析浏览器导出的cookie文件，支持Netscape格式（cookies.txt）和浏览器扩展导出的JSON
+func Parse(data []byte) ([]Cookie, error) {
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) > 0 && (trimmed[0] == '[' || trimmed[0] == '{') {
+		return parseJSON(trimmed)
+	}
+	return parseNetscape(data)
+}
+
+// parseNetscape 解析Netscape格式：domain、includeSubdomains、path、secure、expires、name、value，以制表符分隔
+func parseNetscape(data []byte) ([]Cookie, error) {
+	var result []Cookie
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		// HttpOnly的cookie以 #HttpOnly_ 开头，不是注释
+		line = strings.TrimPrefix(line, "#HttpOnly_")
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Split(line, "\t")
+		if len(fields) < 7 {
+			continue
+		}
+		// includeSubdomains为FALSE时只发送给该域名本身
+		c := Cookie{Domain: fields[0], HostOnly: !strings.EqualFold(fields[1], "TRUE"), Name: fields[5], Value: fields[6]}
+		if expires, _ := strconv.ParseInt(fields[4], 10, 64); expires > 0 {
+			c.Expires = time.Unix(expires, 0)
+		}
+		result = append(result, c)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(result) == 0 {
+		return nil, fmt.Errorf("不是有效的cookie文件")
+	}
+	return result, nil
+}
+
+// jsonCookie 浏览器扩展（EditThisCookie、Cookie-Editor等）导出的cookie
+type jsonCookie struct {
+	Name           string  `json:"name"`
+	Value          string  `json:"value"`
+	Domain         string  `json:"domain"`
+	HostOnly       *bool   `json:"hostOnly"`
+	ExpirationDate float64 `json:"expirationDate"`
+	Expires        any     `json:"expires"` // 部分扩展使用expires（秒或时间字符串）
+	Session        bool    `json:"session"`
+}
+
+// parseJSON 解析JSON数组，或包含cookies数组的对象
+func parseJSON(data []byte) ([]Cookie, error) {
+	var list []jsonCookie
+	if data[0] == '{' {
+		var wrapper struct {
+			Cookies []jsonCookie `json:"cookies"`
+		}
+		if err := json.Unmarshal(data, &wrapper); err != nil {
+			return nil, fmt.Errorf("解析JSON cookie失败: %v", err)
+		}
+		list = wrapper.Cookies
+	} else if err := json.Unmarshal(data, &list); err != nil {
+		return nil, fmt.Errorf("解析JSON cookie失败: %v", err)
+	}
+
+	var result []Cookie
+	for _, jc := range list {
+		if jc.Name == "" {
+			continue
+		}
+		// 未导出hostOnly时按域名是否以.开头判断
+		c := Cookie{Name: jc.Name, Value: jc.Value, Domain: jc.Domain, HostOnly: !strings.HasPrefix(jc.Domain, ".")}
+		if jc.HostOnly != nil {
+			c.HostOnly = *jc.HostOnly
+		}
+		if !jc.Session {
+			c.Expires = jsonExpires(jc)
+		}
+		result = append(result, c)
+	}
+	if len(result) == 0 {
+		return nil, fmt.Errorf("JSON中没有cookie")
+	}
+	return result, nil
+}
+
+// jsonExpires 读取过期时间，无法识别时视为会话cookie
+func jsonExpires(jc jsonCookie) time.Time {
+	if jc.ExpirationDate > 0 {
+		return time.Unix(int64(jc.ExpirationDate), 0)
+	}
+	switch v := jc.Expires.(type) {
+	case float64:
+		if v > 0 {
+			return time.Unix(int64(v), 0)
+		}
+	case string:
+		if t, err := time.Parse(time.RFC3339, v); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// filter 只保留请求domain时浏览器会发送且未过期的cookie，同名cookie保留最后一个
+func filter(list []Cookie, domain string, now time.Time) []Cookie {
+	domain = strings.TrimPrefix(strings.ToLower(domain), ".")
+
+	var result []Cookie
+	index := make(map[string]int)
+	for _, c := range list {
+		if domain != "" && !matchDomain(c, domain) {
+			continue
+		}
+		if !c.Expires.IsZero() && c.Expires.Before(now) {
+			continue
+		}
+		if i, ok := index[c.Name]; ok {
+			result[i] = c
+			continue
+		}
+		index[c.Name] = len(result)
+		result = append(result, c)
+	}
+	return result
+}
+
+// matchDomain 请求domain时浏览器是否会发送该cookie：host-only的cookie要求域名完全相同，
+// 其他cookie的域名可以是domain的父域名，子域名的cookie不会发送给domain
+func matchDomain(c Cookie, domain string) bool {
+	cookieDomain := strings.TrimPrefix(strings.ToLower(c.Domain), ".")
+	if c.HostOnly {
+		return cookieDomain == domain
+	}
+	return cookieDomain == domain || strings.HasSuffix(domain, "."+cookieDomain)
+}
+
+// Header 生成Cookie请求头，例如 a=1; b=2
+func Header(list []Cookie) string {
+	parts := make([]string, 0, len(list))
+	for _, c := range list {
+		parts = append(parts, c.Name+"="+c.Value)
+	}
+	return strings.Join(parts, "; ")
+}
+
+// diffNames 比较两组cookie，返回新增、删除和变更的名称（不输出值），没有变化时返回空
+func diffNames(old, new []Cookie) string {
+	oldValues := make(map[string]string, len(old))
+	for _, c := range old {
+		oldValues[c.Name] = c.Value
+	}
+
+	var added, changed, removed []string
+	newNames := make(map[string]bool, len(new))
+	for _, c := range new {
+		newNames[c.Name] = true
+		value, ok := oldValues[c.Name]
+		switch {
+		case !ok:
+			added = append(added, c.Name)
+		case value != c.Value:
+			changed = append(changed, c.Name)
+		}
+	}
+	for _, c := range old {
+		if !newNames[c.Name] {
+			removed = append(removed, c.Name)
+		}
+	}
+
+	var parts []string
+	for _, group := range []struct {
+		label string
+		names []string
+	}{{"新增", added}, {"变更", changed}, {"删除", removed}} {
+		if len(group.names) > 0 {
+			sort.Strings(group.names)
+			parts = append(parts, group.label+" "+strings.Join(group.names, ","))
+		}
+	}
+	return strings.Join(parts, "；")
+}
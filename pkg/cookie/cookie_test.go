@@ -0,0 +1,155 @@
+package cookie
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestParseNetscape(t *testing.T) {
+	data := "# Netscape HTTP Cookie File\n" +
+		"\n" +
+		".tonkiang.us\tTRUE\t/\tTRUE\t4102444800\tcf_clearance\tabc\n" +
+		"#HttpOnly_tonkiang.us\tFALSE\t/\tFALSE\t0\tPHPSESSID\txyz\n" +
+		".example.com\tTRUE\t/\tFALSE\t4102444800\tother\t1\n" +
+		".tonkiang.us\tTRUE\t/\tFALSE\t1000\texpired\told\n"
+
+	list, err := Parse([]byte(data))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if len(list) != 4 {
+		t.Fatalf("Parse got %d cookies, want 4", len(list))
+	}
+
+	got := Header(filter(list, "tonkiang.us", time.Now()))
+	if want := "cf_clearance=abc; PHPSESSID=xyz"; got != want {
+		t.Errorf("Header = %q, want %q", got, want)
+	}
+}
+
+func TestParseJSON(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want string
+	}{
+		{"array", `[{"name":"cf_clearance","value":"abc","domain":".tonkiang.us","expirationDate":4102444800.5},
+			{"name":"PHPSESSID","value":"xyz","domain":"tonkiang.us","session":true},
+			{"name":"old","value":"1","domain":"tonkiang.us","expirationDate":1000}]`, "cf_clearance=abc; PHPSESSID=xyz"},
+		// 子域名的cookie不会发送给tonkiang.us
+		{"object", `{"url":"https://tonkiang.us","cookies":[{"name":"a","value":"1","domain":"tonkiang.us","hostOnly":true,"expires":"2100-01-01T00:00:00Z"},
+			{"name":"b","value":"2","domain":"www.tonkiang.us","hostOnly":false}]}`, "a=1"},
+	}
+	for _, tt := range tests {
+		list, err := Parse([]byte(tt.data))
+		if err != nil {
+			t.Fatalf("%s: Parse: %v", tt.name, err)
+		}
+		if got := Header(filter(list, "tonkiang.us", time.Now())); got != tt.want {
+			t.Errorf("%s: Header = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestMatchDomain(t *testing.T) {
+	tests := []struct {
+		domain   string
+		hostOnly bool
+		want     bool
+	}{
+		{"tonkiang.us", true, true},
+		{"tonkiang.us", false, true},
+		{".tonkiang.us", false, true},
+		{"www.tonkiang.us", false, false},
+		{"www.tonkiang.us", true, false},
+		{"nottonkiang.us", false, false},
+	}
+	for _, tt := range tests {
+		c := Cookie{Domain: tt.domain, HostOnly: tt.hostOnly}
+		if got := matchDomain(c, "tonkiang.us"); got != tt.want {
+			t.Errorf("matchDomain(%q, hostOnly=%v) = %v, want %v", tt.domain, tt.hostOnly, got, tt.want)
+		}
+	}
+
+	// Netscape格式的includeSubdomains为FALSE时是host-only的cookie，父域名的host-only cookie不会使用
+	data := "tonkiang.us\tFALSE\t/\tFALSE\t0\ta\t1\n" +
+		".tonkiang.us\tTRUE\t/\tFALSE\t0\tb\t2\n" +
+		"www.tonkiang.us\tFALSE\t/\tFALSE\t0\tc\t3\n"
+	list, err := Parse([]byte(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := Header(filter(list, "www.tonkiang.us", time.Now())), "b=2; c=3"; got != want {
+		t.Errorf("Header = %q, want %q", got, want)
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	for _, data := range []string{"", "not a cookie file", "[{]"} {
+		if _, err := Parse([]byte(data)); err == nil {
+			t.Errorf("Parse(%q) should fail", data)
+		}
+	}
+}
+
+func TestDiffNames(t *testing.T) {
+	old := []Cookie{{Name: "a", Value: "1"}, {Name: "b", Value: "2"}}
+	new := []Cookie{{Name: "a", Value: "1"}, {Name: "b", Value: "3"}, {Name: "c", Value: "4"}}
+	if got, want := diffNames(old, new), "新增 c；变更 b"; got != want {
+		t.Errorf("diffNames = %q, want %q", got, want)
+	}
+	if got := diffNames(old, old); got != "" {
+		t.Errorf("diffNames of same cookies = %q, want empty", got)
+	}
+}
+
+func TestReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cookies.txt")
+	write := func(value string) {
+		line := ".tonkiang.us\tTRUE\t/\tTRUE\t4102444800\tcf_clearance\t" + value + "\n"
+		if err := os.WriteFile(path, []byte(line), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	write("first")
+	if err := reload(path, "tonkiang.us"); err != nil {
+		t.Fatalf("reload: %v", err)
+	}
+	if got := Get(); got != "cf_clearance=first" {
+		t.Errorf("Get = %q, want cf_clearance=first", got)
+	}
+
+	write("second")
+	if err := reload(path, "tonkiang.us"); err != nil {
+		t.Fatalf("reload: %v", err)
+	}
+	if got := Get(); got != "cf_clearance=second" {
+		t.Errorf("Get = %q, want cf_clearance=second", got)
+	}
+
+	// 文件无效时保留原有cookie
+	if err := os.WriteFile(path, []byte("broken"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := reload(path, "tonkiang.us"); err == nil {
+		t.Error("reload of invalid file should fail")
+	}
+	if got := Get(); got != "cf_clearance=second" {
+		t.Errorf("Get after invalid file = %q, want cf_clearance=second", got)
+	}
+
+	// 加载失败时不记录修改时间，文件写完后可以重新加载
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mu.RLock()
+	recorded := info.ModTime().Equal(modTime) && info.Size() == size
+	mu.RUnlock()
+	if recorded {
+		t.Error("modification time of invalid file recorded")
+	}
+}
@@ -0,0 +1,173 @@
+package hls
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Variant 主播放列表中的一路码流
+type Variant struct {
+	URI        string
+	Bandwidth  int    // 声明带宽（bps）
+	Resolution string // 例如 1920x1080
+	Codecs     string // 例如 avc1.64001f,mp4a.40.2
+}
+
+// Segment 媒体播放列表中的分片
+type Segment struct {
+	URI      string
+	Duration float64
+}
+
+// Playlist 解析后的播放列表
+type Playlist struct {
+	Master         bool // 是否为主播放列表（包含多路码流）
+	Variants       []Variant
+	Segments       []Segment
+	TargetDuration int
+	Ended          bool // 是否包含#EXT-X-ENDLIST（点播）
+}
+
+// IsPlaylist 判断数据是否为m3u8播放列表
+func IsPlaylist(data []byte) bool {
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+	return bytes.HasPrefix(bytes.TrimSpace(data), []byte("#EXTM3U"))
+}
+
+// Parse 解析m3u8播放列表
+func Parse(data []byte) (*Playlist, error) {
+	if !IsPlaylist(data) {
+		return nil, fmt.Errorf("不是有效的m3u8播放列表")
+	}
+
+	playlist := &Playlist{}
+	var pendingVariant *Variant
+	var pendingDuration float64
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		switch {
+		case strings.HasPrefix(line, "#EXT-X-STREAM-INF:"):
+			attrs := ParseAttributes(strings.TrimPrefix(line, "#EXT-X-STREAM-INF:"))
+			bandwidth, _ := strconv.Atoi(attrs["BANDWIDTH"])
+			pendingVariant = &Variant{
+				Bandwidth:  bandwidth,
+				Resolution: attrs["RESOLUTION"],
+				Codecs:     attrs["CODECS"],
+			}
+		case strings.HasPrefix(line, "#EXTINF:"):
+			value := strings.TrimPrefix(line, "#EXTINF:")
+			if idx := strings.Index(value, ","); idx >= 0 {
+				value = value[:idx]
+			}
+			pendingDuration, _ = strconv.ParseFloat(strings.TrimSpace(value), 64)
+		case strings.HasPrefix(line, "#EXT-X-TARGETDURATION:"):
+			playlist.TargetDuration, _ = strconv.Atoi(strings.TrimPrefix(line, "#EXT-X-TARGETDURATION:"))
+		case line == "#EXT-X-ENDLIST":
+			playlist.Ended = true
+		case strings.HasPrefix(line, "#"):
+			// 其他标签和注释忽略
+		default:
+			if pendingVariant != nil {
+				pendingVariant.URI = line
+				playlist.Variants = append(playlist.Variants, *pendingVariant)
+				pendingVariant = nil
+			} else {
+				playlist.Segments = append(playlist.Segments, Segment{URI: line, Duration: pendingDuration})
+				pendingDuration = 0
+			}
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("读取播放列表失败: %v", err)
+	}
+
+	playlist.Master = len(playlist.Variants) > 0
+	return playlist, nil
+}
+
+// ParseAttributes 解析属性列表，例如 BANDWIDTH=1280000,CODECS="avc1.4d401f,mp4a.40.2"
+func ParseAttributes(s string) map[string]string {
+	attrs := make(map[string]string)
+	for len(s) > 0 {
+		eq := strings.Index(s, "=")
+		if eq < 0 {
+			break
+		}
+		key := strings.TrimSpace(s[:eq])
+		s = s[eq+1:]
+
+		var value string
+		if strings.HasPrefix(s, `"`) {
+			end := strings.Index(s[1:], `"`)
+			if end < 0 {
+				value, s = s[1:], ""
+			} else {
+				value, s = s[1:end+1], s[end+2:]
+			}
+		} else if comma := strings.Index(s, ","); comma >= 0 {
+			value, s = s[:comma], s[comma:]
+		} else {
+			value, s = s, ""
+		}
+		attrs[strings.ToUpper(key)] = strings.TrimSpace(value)
+
+		s = strings.TrimPrefix(strings.TrimSpace(s), ",")
+	}
+	return attrs
+}
+
+// ResolveURI 将播放列表中的相对地址解析为绝对地址
+func ResolveURI(base string, ref string) string {
+	baseURL, err := url.Parse(base)
+	if err != nil {
+		return ref
+	}
+	refURL, err := url.Parse(ref)
+	if err != nil {
+		return ref
+	}
+	return baseURL.ResolveReference(refURL).String()
+}
+
+// uriAttrPattern 匹配标签中的URI属性（EXT-X-KEY、EXT-X-MAP、EXT-X-MEDIA等）
+var uriAttrPattern = regexp.MustCompile(`URI="([^"]*)"`)
+
+// Rewrite 改写播放列表中的所有地址（码流、分片、密钥、初始化分片等），
+// mapURI 接收解析后的绝对地址，返回改写后的地址
+func Rewrite(data []byte, baseURL string, mapURI func(absolute string) string) []byte {
+	var out bytes.Buffer
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		trimmed := strings.TrimSpace(line)
+
+		switch {
+		case trimmed == "":
+		case strings.HasPrefix(trimmed, "#"):
+			line = uriAttrPattern.ReplaceAllStringFunc(line, func(attr string) string {
+				uri := uriAttrPattern.FindStringSubmatch(attr)[1]
+				return `URI="` + mapURI(ResolveURI(baseURL, uri)) + `"`
+			})
+		default:
+			line = mapURI(ResolveURI(baseURL, trimmed))
+		}
+
+		out.WriteString(line)
+		out.WriteByte('\n')
+	}
+	return out.Bytes()
+}
@@ -0,0 +1,67 @@
+package hls
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+)
+
+const masterPlaylist = `#EXTM3U
+#EXT-X-STREAM-INF:BANDWIDTH=1280000,RESOLUTION=1280x720,CODECS="avc1.4d401f,mp4a.40.2"
+720p/index.m3u8
+#EXT-X-STREAM-INF:BANDWIDTH=5000000,RESOLUTION=1920x1080,CODECS="avc1.640028,mp4a.40.2"
+http://cdn.example.com/1080p/index.m3u8
+`
+
+const mediaPlaylist = `#EXTM3U
+#EXT-X-TARGETDURATION:6
+#EXT-X-KEY:METHOD=AES-128,URI="key.bin"
+#EXTINF:6.000,
+seg1.ts
+#EXTINF:5.5,
+/abs/seg2.ts
+`
+
+func TestParseMaster(t *testing.T) {
+	playlist, err := Parse([]byte(masterPlaylist))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if !playlist.Master || len(playlist.Variants) != 2 {
+		t.Fatalf("variants = %d, master = %v", len(playlist.Variants), playlist.Master)
+	}
+	v := playlist.Variants[0]
+	if v.Bandwidth != 1280000 || v.Resolution != "1280x720" || v.Codecs != "avc1.4d401f,mp4a.40.2" || v.URI != "720p/index.m3u8" {
+		t.Errorf("variant = %+v", v)
+	}
+}
+
+func TestParseMedia(t *testing.T) {
+	playlist, err := Parse([]byte(mediaPlaylist))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if playlist.Master || len(playlist.Segments) != 2 || playlist.TargetDuration != 6 {
+		t.Fatalf("playlist = %+v", playlist)
+	}
+	if playlist.Segments[1].Duration != 5.5 {
+		t.Errorf("duration = %v", playlist.Segments[1].Duration)
+	}
+}
+
+func TestRewrite(t *testing.T) {
+	out := string(Rewrite([]byte(mediaPlaylist), "http://origin.example.com/live/ch1/index.m3u8", func(abs string) string {
+		return "/proxy?url=" + url.QueryEscape(abs)
+	}))
+
+	for _, want := range []string{
+		`URI="/proxy?url=` + url.QueryEscape("http://origin.example.com/live/ch1/key.bin") + `"`,
+		"/proxy?url=" + url.QueryEscape("http://origin.example.com/live/ch1/seg1.ts"),
+		"/proxy?url=" + url.QueryEscape("http://origin.example.com/abs/seg2.ts"),
+		"#EXTINF:6.000,",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("rewritten playlist missing %q:\n%s", want, out)
+		}
+	}
+}
@@ -9,10 +9,10 @@ import (
 	httppkg "iptv/pkg/http"
 )
 
-// FetchHTML 获取HTML内容
-func FetchHTML(url string, cookies string, referer string) (*goquery.Document, error) {
+// FetchHTML 获取HTML内容，client为空时使用默认客户端
+func FetchHTML(client *httppkg.Client, url string, cookies string, referer string) (*goquery.Document, error) {
 	headers := httppkg.GetHTMLHeaders(referer)
-	body, err := httppkg.GetBody(url, headers, cookies)
+	body, err := clientOrDefault(client).GetBody(url, headers, cookies)
 	if err != nil {
 		return nil, err
 	}
@@ -20,10 +20,10 @@ func FetchHTML(url string, cookies string, referer string) (*goquery.Document, e
 	return goquery.NewDocumentFromReader(bytes.NewReader(body))
 }
 
-// FetchHTMLForAPI 获取API的HTML内容（用于getall.php等API请求）
-func FetchHTMLForAPI(url string, cookies string, referer string) (*goquery.Document, error) {
+// FetchHTMLForAPI 获取API的HTML内容（用于getall.php等API请求），client为空时使用默认客户端
+func FetchHTMLForAPI(client *httppkg.Client, url string, cookies string, referer string) (*goquery.Document, error) {
 	headers := httppkg.GetAPIHeaders(referer)
-	body, err := httppkg.GetBody(url, headers, cookies)
+	body, err := clientOrDefault(client).GetBody(url, headers, cookies)
 	if err != nil {
 		return nil, err
 	}
@@ -31,10 +31,18 @@ func FetchHTMLForAPI(url string, cookies string, referer string) (*goquery.Docum
 	return goquery.NewDocumentFromReader(bytes.NewReader(body))
 }
 
-// FetchHTMLRaw 获取原始HTML内容（返回io.Reader）
-func FetchHTMLRaw(url string, cookies string, referer string) (io.Reader, error) {
+// FetchHTMLRaw 获取原始HTML内容（返回io.Reader），client为空时使用默认客户端
+func FetchHTMLRaw(client *httppkg.Client, url string, cookies string, referer string) (io.Reader, error) {
 	headers := httppkg.GetHTMLHeaders(referer)
-	return httppkg.GetReader(url, headers, cookies)
+	return clientOrDefault(client).GetReader(url, headers, cookies)
+}
+
+// clientOrDefault client为空时返回默认客户端
+func clientOrDefault(client *httppkg.Client) *httppkg.Client {
+	if client == nil {
+		return httppkg.GetClient()
+	}
+	return client
 }
 
 // ExtractLinks 从文档中提取链接
@@ -0,0 +1,164 @@
+package http
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrorKind 请求错误的类型
+type ErrorKind string
+
+const (
+	KindTimeout   ErrorKind = "timeout"   // 连接或读取超时
+	KindDNS       ErrorKind = "dns"       // 域名解析失败
+	KindTLS       ErrorKind = "tls"       // TLS握手或证书错误
+	KindHTTP4xx   ErrorKind = "http4xx"   // HTTP 4xx 响应
+	KindHTTP5xx   ErrorKind = "http5xx"   // HTTP 5xx 响应
+	KindChallenge ErrorKind = "challenge" // 验证页面
+	KindNetwork   ErrorKind = "network"   // 其他连接错误，例如连接被拒绝、连接重置、没有可用代理
+	KindOther     ErrorKind = "other"     // 非请求错误，例如解析失败、读取文件失败
+)
+
+// ErrorKinds 所有错误类型，按统计输出的顺序排列
+var ErrorKinds = []ErrorKind{KindTimeout, KindDNS, KindTLS, KindHTTP4xx, KindHTTP5xx, KindChallenge, KindNetwork, KindOther}
+
+// Label 错误类型的中文名称
+func (k ErrorKind) Label() string {
+	switch k {
+	case KindTimeout:
+		return "超时"
+	case KindDNS:
+		return "DNS"
+	case KindTLS:
+		return "TLS"
+	case KindHTTP4xx:
+		return "HTTP 4xx"
+	case KindHTTP5xx:
+		return "HTTP 5xx"
+	case KindChallenge:
+		return "验证页面"
+	case KindNetwork:
+		return "网络"
+	}
+	return "其他"
+}
+
+// Error 分类后的请求错误
+type Error struct {
+	Kind       ErrorKind
+	Method     string
+	StatusCode int           // HTTP状态码，连接错误时为0
+	Proxy      string        // 使用的代理，未使用代理池时为空
+	RetryAfter time.Duration // 429/503响应的Retry-After
+	Err        error         // 原始错误，验证页面为ErrChallenge
+}
+
+// Error 错误信息
+func (e *Error) Error() string {
+	via := ""
+	if e.Proxy != "" {
+		via = "（代理 " + e.Proxy + "）"
+	}
+	switch {
+	case e.Kind == KindChallenge:
+		return fmt.Sprintf("%v（HTTP %d）%s", e.Err, e.StatusCode, via)
+	case e.StatusCode != 0:
+		return fmt.Sprintf("HTTP错误: %d%s", e.StatusCode, via)
+	}
+	return fmt.Sprintf("%s请求失败%s: %v", e.Method, via, e.Err)
+}
+
+// Unwrap 原始错误
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// KindOf 错误的类型，不是请求错误时返回KindOther
+func KindOf(err error) ErrorKind {
+	var e *Error
+	if errors.As(err, &e) {
+		return e.Kind
+	}
+	if errors.Is(err, ErrChallenge) {
+		return KindChallenge
+	}
+	return KindOther
+}
+
+// classify 按原因对请求失败（没有收到响应）分类
+func classify(err error) ErrorKind {
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		// DNS服务器超时也算DNS错误
+		return KindDNS
+	}
+
+	var netErr net.Error
+	if errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout()) {
+		return KindTimeout
+	}
+
+	var (
+		recordErr    tls.RecordHeaderError
+		alertErr     tls.AlertError
+		verifyErr    *tls.CertificateVerificationError
+		authorityErr x509.UnknownAuthorityError
+		hostnameErr  x509.HostnameError
+		invalidErr   x509.CertificateInvalidError
+	)
+	if errors.As(err, &recordErr) || errors.As(err, &alertErr) || errors.As(err, &verifyErr) ||
+		errors.As(err, &authorityErr) || errors.As(err, &hostnameErr) || errors.As(err, &invalidErr) ||
+		strings.Contains(err.Error(), "tls: ") {
+		return KindTLS
+	}
+	return KindNetwork
+}
+
+// statusKind 按状态码分类
+func statusKind(statusCode int) ErrorKind {
+	if statusCode >= 500 {
+		return KindHTTP5xx
+	}
+	return KindHTTP4xx
+}
+
+// retryable 错误是否值得重试：超时、连接错误、5xx和429可以重试，
+// DNS（除临时错误外）、TLS、其他4xx和验证页面重试也不会成功
+func retryable(err *Error) bool {
+	switch err.Kind {
+	case KindTimeout, KindNetwork, KindHTTP5xx:
+		return !errors.Is(err.Err, ErrNoProxy)
+	case KindHTTP4xx:
+		return err.StatusCode == http.StatusTooManyRequests
+	case KindDNS:
+		var dnsErr *net.DNSError
+		return errors.As(err.Err, &dnsErr) && (dnsErr.IsTemporary || dnsErr.IsTimeout)
+	}
+	return false
+}
+
+// parseRetryAfter 解析Retry-After：秒数或HTTP日期，无法解析时返回0
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+	if t, err := http.ParseTime(value); err == nil && t.After(now) {
+		return t.Sub(now)
+	}
+	return 0
+}
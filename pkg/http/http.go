@@ -2,57 +2,140 @@ package http
 
 import (
 	"bytes"
+	"context"
+	"errors"
 	"fmt"
 	"io"
+	"net/http"
 	"time"
 
 	"github.com/go-resty/resty/v2"
 	"iptv/pkg/config"
+	"iptv/pkg/log"
 )
 
+// ErrChallenge Cloudflare质询或网站验证页面，通常是cf_clearance等cookie已过期
+var ErrChallenge = errors.New("遇到验证页面，cookie可能已过期")
+
+// challengeMarkers 质询/验证页面的特征，任何状态码的响应包含时都视为验证页面
+var challengeMarkers = [][]byte{
+	[]byte("<title>Just a moment...</title>"),
+	[]byte("window._cf_chl_opt"),
+	[]byte("cf-browser-verification"),
+	[]byte("Attention Required! | Cloudflare"),
+	[]byte("Checking your browser before accessing"),
+	[]byte("Verify you are human"),
+	[]byte("请完成安全验证"),
+}
+
+// blockedMarkers 403/429/503响应包含时视为验证页面（正常页面也可能引用challenge-platform脚本，只在这些状态码下判断）
+var blockedMarkers = [][]byte{
+	[]byte("challenge-platform"),
+	[]byte("cf-chl"),
+	[]byte("cf-error-details"),
+	[]byte("Cloudflare Ray ID"),
+}
+
+// Client 使用某个浏览器配置发送请求的HTTP客户端
+type Client struct {
+	resty   *resty.Client
+	profile config.HTTPProfile
+	headers http.Header // 浏览器配置的请求头
+	proxies *ProxyPool  // 代理池，为空时直连
+	retry   retryPolicy
+}
+
+// Options 创建客户端的选项
+type Options struct {
+	Profile string   // 浏览器配置名称，为空时使用 cookie.profile 或 http.profile
+	Proxies []string // 代理池，为空时使用 http.proxies，包含 direct 表示可以直连
+	// RetryUnsafe POST等非幂等请求失败时也按重试策略重试，只在重复请求没有副作用时开启
+	RetryUnsafe bool
+}
+
 var (
-	client *resty.Client
+	client *Client
 )
 
-// Init 初始化HTTP客户端
+// Init 初始化默认HTTP客户端
 func Init() error {
-	cfg := config.GetConfig()
-	if cfg == nil {
+	if config.GetConfig() == nil {
 		return fmt.Errorf("配置未加载")
 	}
 
-	// 创建resty客户端
-	client = resty.New()
+	c, err := NewClient(Options{})
+	if err != nil {
+		return err
+	}
+	client = c
+	return nil
+}
+
+// NewClient 创建使用指定浏览器配置的HTTP客户端（例如来源配置了profile时）
+func NewClient(opts Options) (*Client, error) {
+	profile, err := FindProfile(profileName(opts.Profile))
+	if err != nil {
+		return nil, err
+	}
 
 	// 设置超时时间（从配置读取，默认30秒）
 	timeout := 30
-	if cfg.HTTP.Timeout > 0 {
+	cfg := config.GetConfig()
+	if cfg != nil && cfg.HTTP.Timeout > 0 {
 		timeout = cfg.HTTP.Timeout
 	}
-	client.SetTimeout(time.Duration(timeout) * time.Second)
 
-	// 设置默认请求头
-	client.SetHeader("User-Agent", "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/143.0.0.0 Safari/537.36")
-	client.SetHeader("Accept-Language", "zh-CN,zh;q=0.9")
-	client.SetHeader("Dnt", "1")
-	client.SetHeader("Sec-Ch-Ua", `"Google Chrome";v="143", "Chromium";v="143", "Not A(Brand";v="24"`)
-	client.SetHeader("Sec-Ch-Ua-Mobile", "?0")
-	client.SetHeader("Sec-Ch-Ua-Platform", `"macOS"`)
+	c := &Client{profile: profile, headers: profileHeaders(profile), retry: loadRetryPolicy()}
+	c.retry.unsafe = opts.RetryUnsafe
 
-	return nil
+	proxies := opts.Proxies
+	if len(proxies) == 0 && cfg != nil {
+		proxies = cfg.HTTP.Proxies
+	}
+	if len(proxies) > 0 {
+		c.proxies, err = sharedPool(proxies)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	c.resty = resty.New()
+	c.resty.SetTimeout(time.Duration(timeout) * time.Second)
+	switch {
+	case len(profile.HeaderOrder) > 0:
+		c.resty.SetTransport(newOrderedTransport(profile.HeaderOrder))
+	case c.proxies != nil:
+		c.resty.SetTransport(newProxyTransport())
+	}
+
+	log.Debug("HTTP客户端使用浏览器配置: %s，代理: %v", profile.Name, proxies)
+	return c, nil
 }
 
-// GetClient 获取HTTP客户端
-func GetClient() *resty.Client {
+// Profile 客户端使用的浏览器配置名称
+func (c *Client) Profile() string {
+	return c.profile.Name
+}
+
+// GetClient 获取默认HTTP客户端
+func GetClient() *Client {
 	if client == nil {
 		Init()
 	}
+	if client == nil {
+		// 配置未加载时使用默认浏览器配置
+		client, _ = NewClient(Options{Profile: DefaultProfile})
+	}
 	return client
 }
 
-// Get 执行GET请求
-func Get(url string, headers map[string]string, cookies string) (*resty.Response, error) {
-	req := GetClient().R()
+// request 创建请求：浏览器配置的请求头、请求的请求头和Cookie依次设置，后设置的覆盖先设置的
+// 使用代理池时为请求选择代理，返回的proxyState为空表示未使用代理池
+func (c *Client) request(method, url string, body interface{}, headers map[string]string, cookies string) (*resty.Request, *proxyState, *Error) {
+	req := c.resty.R()
+	for k := range c.headers {
+		req.SetHeader(k, c.headers.Get(k))
+	}
 
 	// 设置请求头
 	if headers != nil {
@@ -66,65 +149,145 @@ func Get(url string, headers map[string]string, cookies string) (*resty.Response
 		req.SetHeader("Cookie", cookies)
 	}
 
+	// 设置请求体
+	if body != nil {
+		req.SetBody(body)
+	}
+
+	if c.proxies == nil {
+		return req, nil, nil
+	}
+	state, err := c.proxies.pick()
+	if err != nil {
+		return nil, nil, &Error{Kind: KindNetwork, Method: method, Err: err}
+	}
+	log.Debug("%s %s 使用代理 %s", method, url, state.name)
+	req.SetContext(context.WithValue(context.Background(), proxyKey{}, state))
+	return req, state, nil
+}
+
+// do 执行请求，可以重试的错误按重试策略重试（每次重试重新选择代理）
+func (c *Client) do(method, url string, body interface{}, headers map[string]string, cookies string) (*resty.Response, error) {
+	for attempt := 1; ; attempt++ {
+		resp, err := c.attempt(method, url, body, headers, cookies)
+		if err == nil {
+			return resp, nil
+		}
+
+		wait, ok := c.retry.delay(err, attempt)
+		if !ok {
+			return nil, err
+		}
+		log.Warn("%s %s 失败（%s），%v后第%d次重试: %v", method, url, err.Kind.Label(), wait.Round(time.Millisecond), attempt, err)
+		sleep(wait)
+	}
+}
+
+// attempt 执行一次请求：记录代理的请求结果（只有代理本身的错误计为代理失败），检查并分类响应
+func (c *Client) attempt(method, url string, body interface{}, headers map[string]string, cookies string) (*resty.Response, *Error) {
+	req, state, reqErr := c.request(method, url, body, headers, cookies)
+	if reqErr != nil {
+		return nil, reqErr
+	}
+
 	// 执行请求
-	resp, err := req.Get(url)
+	resp, err := req.Execute(method, url)
+
+	proxyName := ""
+	if state != nil {
+		status := 0
+		if err == nil {
+			status = resp.StatusCode()
+		}
+		c.proxies.report(state, proxyFailure(err, status))
+		proxyName = state.name
+	}
+
 	if err != nil {
-		return nil, fmt.Errorf("GET请求失败: %v", err)
+		return nil, &Error{Kind: classify(err), Method: method, Proxy: proxyName, Err: err}
 	}
 
-	if resp.StatusCode() != 200 {
-		return nil, fmt.Errorf("HTTP错误: %d", resp.StatusCode())
+	status := resp.StatusCode()
+	if IsChallenge(status, resp.Header(), resp.Body()) {
+		return nil, &Error{Kind: KindChallenge, Method: method, StatusCode: status, Proxy: proxyName, Err: ErrChallenge}
+	}
+
+	if status != 200 {
+		e := &Error{Kind: statusKind(status), Method: method, StatusCode: status, Proxy: proxyName, Err: fmt.Errorf("HTTP %d", status)}
+		if status == http.StatusTooManyRequests || status == http.StatusServiceUnavailable {
+			e.RetryAfter = parseRetryAfter(resp.Header().Get("Retry-After"), time.Now())
+		}
+		return nil, e
 	}
 
 	return resp, nil
 }
 
-// Post 执行POST请求
-func Post(url string, body interface{}, headers map[string]string, cookies string) (*resty.Response, error) {
-	req := GetClient().R()
+// Get 使用默认客户端执行GET请求
+func Get(url string, headers map[string]string, cookies string) (*resty.Response, error) {
+	return GetClient().Get(url, headers, cookies)
+}
 
-	// 设置请求体
-	if body != nil {
-		req.SetBody(body)
+// Get 执行GET请求
+func (c *Client) Get(url string, headers map[string]string, cookies string) (*resty.Response, error) {
+	return c.do(http.MethodGet, url, nil, headers, cookies)
+}
+
+// IsChallenge 判断响应是否为Cloudflare质询或网站验证页面
+func IsChallenge(statusCode int, header http.Header, body []byte) bool {
+	if header.Get("Cf-Mitigated") == "challenge" {
+		return true
 	}
 
-	// 设置请求头
-	if headers != nil {
-		for k, v := range headers {
-			req.SetHeader(k, v)
+	for _, marker := range challengeMarkers {
+		if bytes.Contains(body, marker) {
+			return true
 		}
 	}
 
-	// 设置Cookie
-	if cookies != "" {
-		req.SetHeader("Cookie", cookies)
+	switch statusCode {
+	case http.StatusForbidden, http.StatusTooManyRequests, http.StatusServiceUnavailable:
+		for _, marker := range blockedMarkers {
+			if bytes.Contains(body, marker) {
+				return true
+			}
+		}
 	}
+	return false
+}
 
-	// 执行请求
-	resp, err := req.Post(url)
-	if err != nil {
-		return nil, fmt.Errorf("POST请求失败: %v", err)
-	}
+// Post 使用默认客户端执行POST请求
+func Post(url string, body interface{}, headers map[string]string, cookies string) (*resty.Response, error) {
+	return GetClient().Post(url, body, headers, cookies)
+}
 
-	if resp.StatusCode() != 200 {
-		return nil, fmt.Errorf("HTTP错误: %d", resp.StatusCode())
-	}
+// Post 执行POST请求
+func (c *Client) Post(url string, body interface{}, headers map[string]string, cookies string) (*resty.Response, error) {
+	return c.do(http.MethodPost, url, body, headers, cookies)
+}
 
-	return resp, nil
+// GetBody 使用默认客户端执行GET请求并返回响应体
+func GetBody(url string, headers map[string]string, cookies string) ([]byte, error) {
+	return GetClient().GetBody(url, headers, cookies)
 }
 
 // GetBody 执行GET请求并返回响应体
-func GetBody(url string, headers map[string]string, cookies string) ([]byte, error) {
-	resp, err := Get(url, headers, cookies)
+func (c *Client) GetBody(url string, headers map[string]string, cookies string) ([]byte, error) {
+	resp, err := c.Get(url, headers, cookies)
 	if err != nil {
 		return nil, err
 	}
 	return resp.Body(), nil
 }
 
-// GetReader 执行GET请求并返回io.Reader
+// GetReader 使用默认客户端执行GET请求并返回io.Reader
 func GetReader(url string, headers map[string]string, cookies string) (io.Reader, error) {
-	body, err := GetBody(url, headers, cookies)
+	return GetClient().GetReader(url, headers, cookies)
+}
+
+// GetReader 执行GET请求并返回io.Reader
+func (c *Client) GetReader(url string, headers map[string]string, cookies string) (io.Reader, error) {
+	body, err := c.GetBody(url, headers, cookies)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,29 @@
+package http
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestIsChallenge(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		header http.Header
+		body   string
+		want   bool
+	}{
+		{"cf-mitigated header", 403, http.Header{"Cf-Mitigated": {"challenge"}}, "", true},
+		{"interstitial page", 503, nil, "<html><head><title>Just a moment...</title>", true},
+		{"challenge with 200", 200, nil, "<script>window._cf_chl_opt={cvId:'3'}</script>", true},
+		{"403 challenge body", 403, nil, `<script src="/cdn-cgi/challenge-platform/h/b/orchestrate/chl_page/v1"></script>`, true},
+		{"normal page with bot script", 200, nil, `<div class="result"></div><script src="/cdn-cgi/challenge-platform/scripts/jsd/main.js"></script>`, false},
+		{"plain 403", 403, nil, "Forbidden", false},
+		{"normal page", 200, nil, `<div class="result">CCTV1</div>`, false},
+	}
+	for _, tt := range tests {
+		if got := IsChallenge(tt.status, tt.header, []byte(tt.body)); got != tt.want {
+			t.Errorf("%s: IsChallenge = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,218 @@
+package http
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/textproto"
+	"sort"
+	"strings"
+)
+
+// orderedTransport 按指定顺序发送请求头的HTTP/1.1传输
+// net/http总是按字母顺序写请求头，与浏览器的顺序不同；每个请求使用单独的连接
+type orderedTransport struct {
+	order  []string // 请求头顺序，未列出的请求头按字母顺序排在后面
+	dialer *net.Dialer
+}
+
+// newOrderedTransport 创建按order顺序发送请求头的传输
+func newOrderedTransport(order []string) *orderedTransport {
+	canonical := make([]string, 0, len(order))
+	for _, name := range order {
+		canonical = append(canonical, textproto.CanonicalMIMEHeaderKey(name))
+	}
+	return &orderedTransport{order: canonical, dialer: &net.Dialer{}}
+}
+
+// RoundTrip 发送请求并读取响应头，响应体关闭时关闭连接
+func (t *orderedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	body, err := readRequestBody(req)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx := req.Context()
+	conn, err := t.dial(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	// 请求取消或超时时关闭连接，中断读写
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	fail := func(err error) (*http.Response, error) {
+		stop()
+		conn.Close()
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, ctxErr
+		}
+		return nil, err
+	}
+
+	// 与net/http一样，未指定Accept-Encoding时请求gzip并自动解压
+	header := req.Header.Clone()
+	decompress := header.Get("Accept-Encoding") == "" && req.Method != http.MethodHead
+	if decompress {
+		header.Set("Accept-Encoding", "gzip")
+	}
+
+	w := bufio.NewWriter(conn)
+	writeRequest(w, req, header, body, t.order)
+	if err := w.Flush(); err != nil {
+		return fail(err)
+	}
+
+	resp, err := http.ReadResponse(bufio.NewReader(conn), req)
+	if err != nil {
+		return fail(err)
+	}
+	if decompress && strings.EqualFold(resp.Header.Get("Content-Encoding"), "gzip") {
+		resp.Header.Del("Content-Encoding")
+		resp.Header.Del("Content-Length")
+		resp.ContentLength = -1
+		resp.Uncompressed = true
+		resp.Body = &gzipBody{body: resp.Body}
+	}
+	resp.Body = &connBody{ReadCloser: resp.Body, conn: conn, stop: stop}
+	return resp, nil
+}
+
+// dial 连接请求的服务器（请求使用代理时经过代理），https使用TLS并只协商HTTP/1.1
+func (t *orderedTransport) dial(ctx context.Context, req *http.Request) (net.Conn, error) {
+	host := req.URL.Hostname()
+	port := req.URL.Port()
+	if port == "" {
+		port = "80"
+		if req.URL.Scheme == "https" {
+			port = "443"
+		}
+	}
+
+	addr := net.JoinHostPort(host, port)
+	var conn net.Conn
+	var err error
+	if proxyURL, _ := contextProxy(req); proxyURL != nil {
+		// 通过代理建立隧道，http目标同样使用CONNECT
+		conn, err = dialProxy(ctx, t.dialer, proxyURL, addr)
+	} else {
+		conn, err = t.dialer.DialContext(ctx, "tcp", addr)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if req.URL.Scheme != "https" {
+		return conn, nil
+	}
+
+	tlsConn := tls.Client(conn, &tls.Config{ServerName: host, NextProtos: []string{"http/1.1"}})
+	if err := tlsConn.HandshakeContext(ctx); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return tlsConn, nil
+}
+
+// readRequestBody 读取请求体，用于计算Content-Length
+func readRequestBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, nil
+	}
+	defer req.Body.Close()
+	return io.ReadAll(req.Body)
+}
+
+// writeRequest 写请求行和请求头：Host在最前（order中包含Host时按order），然后是order中的请求头，最后是其他请求头
+func writeRequest(w io.Writer, req *http.Request, header http.Header, body []byte, order []string) {
+	host := req.Host
+	if host == "" {
+		host = req.URL.Host
+	}
+	header.Set("Host", host)
+	if body != nil || req.Method == http.MethodPost || req.Method == http.MethodPut {
+		header.Set("Content-Length", fmt.Sprint(len(body)))
+	}
+
+	fmt.Fprintf(w, "%s %s HTTP/1.1\r\n", req.Method, req.URL.RequestURI())
+
+	written := make(map[string]bool)
+	writeHeader := func(name string) {
+		if written[name] {
+			return
+		}
+		written[name] = true
+		for _, value := range header[name] {
+			// 丢弃包含换行的值，避免请求头注入
+			if strings.ContainsAny(value, "\r\n") {
+				continue
+			}
+			fmt.Fprintf(w, "%s: %s\r\n", name, value)
+		}
+	}
+
+	hostOrdered := false
+	for _, name := range order {
+		hostOrdered = hostOrdered || name == "Host"
+	}
+	if !hostOrdered {
+		writeHeader("Host")
+	}
+	for _, name := range order {
+		writeHeader(name)
+	}
+
+	rest := make([]string, 0, len(header))
+	for name := range header {
+		if !written[name] {
+			rest = append(rest, name)
+		}
+	}
+	sort.Strings(rest)
+	for _, name := range rest {
+		writeHeader(name)
+	}
+
+	io.WriteString(w, "\r\n")
+	w.Write(body)
+}
+
+// connBody 响应体，关闭时同时关闭连接
+type connBody struct {
+	io.ReadCloser
+	conn net.Conn
+	stop func() bool
+}
+
+// Close 关闭响应体和连接
+func (b *connBody) Close() error {
+	b.stop()
+	err := b.ReadCloser.Close()
+	b.conn.Close()
+	return err
+}
+
+// gzipBody gzip压缩的响应体，第一次读取时才创建解压器（空响应体没有gzip头）
+type gzipBody struct {
+	body io.ReadCloser
+	zr   *gzip.Reader
+}
+
+// Read 读取解压后的内容
+func (b *gzipBody) Read(p []byte) (int, error) {
+	if b.zr == nil {
+		zr, err := gzip.NewReader(b.body)
+		if err != nil {
+			return 0, err
+		}
+		b.zr = zr
+	}
+	return b.zr.Read(p)
+}
+
+// Close 关闭响应体
+func (b *gzipBody) Close() error {
+	return b.body.Close()
+}
@@ -0,0 +1,130 @@
+package http
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+
+	"iptv/pkg/config"
+)
+
+// DefaultProfile 默认的浏览器配置
+const DefaultProfile = "chrome-mac"
+
+// builtinProfiles 内置的浏览器配置，可在 http.profiles 中用同名配置覆盖
+var builtinProfiles = []config.HTTPProfile{
+	{
+		Name:           "chrome-mac",
+		UserAgent:      "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/143.0.0.0 Safari/537.36",
+		AcceptLanguage: "zh-CN,zh;q=0.9",
+		Headers: map[string]string{
+			"Dnt":                "1",
+			"Sec-Ch-Ua":          `"Google Chrome";v="143", "Chromium";v="143", "Not A(Brand";v="24"`,
+			"Sec-Ch-Ua-Mobile":   "?0",
+			"Sec-Ch-Ua-Platform": `"macOS"`,
+		},
+	},
+	{
+		Name:           "chrome-windows",
+		UserAgent:      "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/143.0.0.0 Safari/537.36",
+		AcceptLanguage: "zh-CN,zh;q=0.9",
+		Headers: map[string]string{
+			"Sec-Ch-Ua":          `"Google Chrome";v="143", "Chromium";v="143", "Not A(Brand";v="24"`,
+			"Sec-Ch-Ua-Mobile":   "?0",
+			"Sec-Ch-Ua-Platform": `"Windows"`,
+		},
+	},
+	{
+		Name:           "edge-windows",
+		UserAgent:      "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/143.0.0.0 Safari/537.36 Edg/143.0.0.0",
+		AcceptLanguage: "zh-CN,zh;q=0.9,en;q=0.8,en-GB;q=0.7,en-US;q=0.6",
+		Headers: map[string]string{
+			"Sec-Ch-Ua":          `"Microsoft Edge";v="143", "Chromium";v="143", "Not A(Brand";v="24"`,
+			"Sec-Ch-Ua-Mobile":   "?0",
+			"Sec-Ch-Ua-Platform": `"Windows"`,
+		},
+	},
+	{
+		// Firefox和Safari不发送Sec-Ch-Ua客户端提示
+		Name:           "firefox-windows",
+		UserAgent:      "Mozilla/5.0 (Windows NT 10.0; Win64; x64; rv:145.0) Gecko/20100101 Firefox/145.0",
+		AcceptLanguage: "zh-CN,zh;q=0.8,zh-TW;q=0.7,zh-HK;q=0.5,en-US;q=0.3,en;q=0.2",
+	},
+	{
+		Name:           "safari-mac",
+		UserAgent:      "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/18.6 Safari/605.1.15",
+		AcceptLanguage: "zh-CN,zh-Hans;q=0.9",
+	},
+}
+
+// profileName 实际使用的配置名称：指定的名称 > cookie.profile > http.profile > 默认配置
+func profileName(name string) string {
+	if name != "" {
+		return name
+	}
+	cfg := config.GetConfig()
+	if cfg != nil && cfg.Cookie.Profile != "" {
+		return cfg.Cookie.Profile
+	}
+	if cfg != nil && cfg.HTTP.Profile != "" {
+		return cfg.HTTP.Profile
+	}
+	return DefaultProfile
+}
+
+// FindProfile 按名称查找浏览器配置，http.profiles 中的配置优先于内置配置
+func FindProfile(name string) (config.HTTPProfile, error) {
+	if cfg := config.GetConfig(); cfg != nil {
+		for _, profile := range cfg.HTTP.Profiles {
+			if profile.Name == name {
+				if profile.UserAgent == "" {
+					return config.HTTPProfile{}, fmt.Errorf("浏览器配置%s没有配置userAgent", name)
+				}
+				return profile, nil
+			}
+		}
+	}
+	for _, profile := range builtinProfiles {
+		if profile.Name == name {
+			return profile, nil
+		}
+	}
+	return config.HTTPProfile{}, fmt.Errorf("未找到浏览器配置: %s（可用: %v）", name, ProfileNames())
+}
+
+// ProfileNames 所有可用的浏览器配置名称
+func ProfileNames() []string {
+	seen := make(map[string]bool)
+	var names []string
+	add := func(name string) {
+		if name != "" && !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	for _, profile := range builtinProfiles {
+		add(profile.Name)
+	}
+	if cfg := config.GetConfig(); cfg != nil {
+		for _, profile := range cfg.HTTP.Profiles {
+			add(profile.Name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// profileHeaders 浏览器配置的请求头
+func profileHeaders(profile config.HTTPProfile) http.Header {
+	header := make(http.Header)
+	for k, v := range profile.Headers {
+		header.Set(k, v)
+	}
+	if profile.UserAgent != "" {
+		header.Set("User-Agent", profile.UserAgent)
+	}
+	if profile.AcceptLanguage != "" {
+		header.Set("Accept-Language", profile.AcceptLanguage)
+	}
+	return header
+}
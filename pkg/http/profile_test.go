@@ -0,0 +1,143 @@
+package http
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"iptv/pkg/config"
+)
+
+// loadConfig 从yaml内容加载测试配置
+func loadConfig(t *testing.T, content string) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "app.yml")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := config.LoadConfig(path); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestProfileSelection(t *testing.T) {
+	loadConfig(t, `
+cookie:
+  profile: firefox-windows
+http:
+  profile: chrome-windows
+  profiles:
+    - name: chrome-windows
+      userAgent: custom-ua
+    - name: broken
+`)
+
+	tests := []struct {
+		name    string
+		profile string
+		wantUA  string
+	}{
+		{"provider profile", "safari-mac", "Version/18.6 Safari"},
+		{"cookie profile", "", "Firefox/145.0"},
+		{"config overrides builtin", "chrome-windows", "custom-ua"},
+	}
+	for _, tt := range tests {
+		c, err := NewClient(Options{Profile: tt.profile})
+		if err != nil {
+			t.Fatalf("%s: NewClient: %v", tt.name, err)
+		}
+		if ua := c.headers.Get("User-Agent"); !strings.Contains(ua, tt.wantUA) {
+			t.Errorf("%s: User-Agent = %q, want %q", tt.name, ua, tt.wantUA)
+		}
+	}
+
+	for _, name := range []string{"missing", "broken"} {
+		if _, err := NewClient(Options{Profile: name}); err == nil {
+			t.Errorf("NewClient(%s) should fail", name)
+		}
+	}
+}
+
+func TestHeaderOrder(t *testing.T) {
+	loadConfig(t, `
+http:
+  profiles:
+    - name: ordered
+      userAgent: test-ua
+      acceptLanguage: zh-CN
+      headers:
+        Sec-Ch-Ua-Platform: '"Windows"'
+      headerOrder: [sec-ch-ua-platform, user-agent, accept, referer, accept-encoding, accept-language, cookie]
+`)
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	// 记录原始请求头顺序，返回gzip压缩的响应
+	received := make(chan []string, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		var names []string
+		r := bufio.NewReader(conn)
+		r.ReadString('\n') // 请求行
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil || line == "\r\n" {
+				break
+			}
+			names = append(names, line[:strings.Index(line, ":")])
+		}
+		received <- names
+
+		var body bytes.Buffer
+		zw := gzip.NewWriter(&body)
+		zw.Write([]byte("<html>ok</html>"))
+		zw.Close()
+		resp := &http.Response{
+			StatusCode:    200,
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        http.Header{"Content-Encoding": {"gzip"}},
+			ContentLength: int64(body.Len()),
+			Body:          http.NoBody,
+		}
+		resp.Write(conn)
+		conn.Write(body.Bytes())
+	}()
+
+	c, err := NewClient(Options{Profile: "ordered"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, err := c.GetBody("http://"+ln.Addr().String()+"/getall.php", map[string]string{
+		"Accept":  "*/*",
+		"Referer": "http://example.com/",
+		"X-Extra": "1",
+	}, "a=1")
+	if err != nil {
+		t.Fatalf("GetBody: %v", err)
+	}
+	if string(body) != "<html>ok</html>" {
+		t.Errorf("body = %q", body)
+	}
+
+	got := strings.Join(<-received, ",")
+	want := "Host,Sec-Ch-Ua-Platform,User-Agent,Accept,Referer,Accept-Encoding,Accept-Language,Cookie,X-Extra"
+	if got != want {
+		t.Errorf("header order = %s, want %s", got, want)
+	}
+}
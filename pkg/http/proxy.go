@@ -0,0 +1,391 @@
+package http
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/proxy"
+	"iptv/pkg/config"
+	"iptv/pkg/log"
+)
+
+const (
+	directProxy            = "direct" // 代理池中表示直连的地址
+	defaultProxyCheckURL   = "https://www.gstatic.com/generate_204"
+	defaultProxyInterval   = 300 // 默认代理健康检查间隔（秒）
+	defaultProxyMaxFailure = 3   // 默认连续失败多少次后移出代理池
+)
+
+// ErrNoProxy 代理池中的代理都不可用
+var ErrNoProxy = errors.New("代理池中没有可用的代理")
+
+var (
+	poolsMu sync.Mutex
+	pools   = make(map[string]*ProxyPool) // 相同代理列表的来源共用一个代理池（共享健康状态）
+)
+
+// ProxyPool 代理池：请求轮流使用可用的代理，连续失败的代理移出轮换，定时检查恢复后重新加入
+type ProxyPool struct {
+	mu          sync.Mutex
+	proxies     []*proxyState
+	next        int
+	maxFailures int
+	checkURL    string
+	interval    time.Duration
+	stop        chan struct{}
+}
+
+// proxyState 代理及其健康状态
+type proxyState struct {
+	url      *url.URL // 为空表示直连
+	name     string   // 日志中显示的名称（隐藏密码）
+	failures int      // 连续失败次数
+	healthy  bool
+}
+
+// proxyKey 请求context中保存本次请求使用的代理
+type proxyKey struct{}
+
+// ParseProxy 解析代理地址，支持 http、https、socks5、socks5h，direct 表示直连（返回nil）
+func ParseProxy(raw string) (*url.URL, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == directProxy {
+		return nil, nil
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return nil, fmt.Errorf("解析代理地址失败: %v", err)
+	}
+	switch u.Scheme {
+	case "http", "https", "socks5", "socks5h":
+	default:
+		return nil, fmt.Errorf("不支持的代理协议: %s（支持 http、https、socks5）", raw)
+	}
+	if u.Hostname() == "" {
+		return nil, fmt.Errorf("代理地址缺少主机: %s", raw)
+	}
+	return u, nil
+}
+
+// NewProxyPool 创建代理池，不会启动健康检查
+func NewProxyPool(proxies []string) (*ProxyPool, error) {
+	pool := &ProxyPool{
+		maxFailures: defaultProxyMaxFailure,
+		checkURL:    defaultProxyCheckURL,
+		interval:    defaultProxyInterval * time.Second,
+	}
+	if cfg := config.GetConfig(); cfg != nil {
+		check := cfg.HTTP.ProxyCheck
+		if check.URL != "" {
+			pool.checkURL = check.URL
+		}
+		if check.Interval > 0 {
+			pool.interval = time.Duration(check.Interval) * time.Second
+		}
+		if check.MaxFailures > 0 {
+			pool.maxFailures = check.MaxFailures
+		}
+	}
+
+	for _, raw := range proxies {
+		u, err := ParseProxy(raw)
+		if err != nil {
+			return nil, err
+		}
+		name := directProxy
+		if u != nil {
+			name = u.Redacted()
+		}
+		pool.proxies = append(pool.proxies, &proxyState{url: u, name: name, healthy: true})
+	}
+	if len(pool.proxies) == 0 {
+		return nil, fmt.Errorf("代理池为空")
+	}
+	return pool, nil
+}
+
+// sharedPool 获取代理列表对应的代理池，第一次创建时启动健康检查
+func sharedPool(proxies []string) (*ProxyPool, error) {
+	key := strings.Join(proxies, "\n")
+
+	poolsMu.Lock()
+	defer poolsMu.Unlock()
+	if pool, ok := pools[key]; ok {
+		return pool, nil
+	}
+
+	pool, err := NewProxyPool(proxies)
+	if err != nil {
+		return nil, err
+	}
+	pool.Start()
+	pools[key] = pool
+	return pool, nil
+}
+
+// pick 按顺序选择下一个可用的代理
+func (p *ProxyPool) pick() (*proxyState, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for i := 0; i < len(p.proxies); i++ {
+		state := p.proxies[(p.next+i)%len(p.proxies)]
+		if state.healthy {
+			p.next = (p.next + i + 1) % len(p.proxies)
+			return state, nil
+		}
+	}
+	return nil, ErrNoProxy
+}
+
+// report 记录请求结果，err为空表示代理正常。连续失败达到上限的代理移出轮换
+func (p *ProxyPool) report(state *proxyState, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if err == nil {
+		state.failures = 0
+		return
+	}
+	state.failures++
+	log.Warn("代理%s请求失败（连续%d次）: %v", state.name, state.failures, err)
+	if state.healthy && state.failures >= p.maxFailures {
+		state.healthy = false
+		log.Warn("代理%s连续失败%d次，已移出代理池", state.name, state.failures)
+	}
+}
+
+// proxyError 代理本身的错误（连接代理、CONNECT隧道、SOCKS5握手失败）
+type proxyError struct {
+	err error
+}
+
+func (e *proxyError) Error() string {
+	return e.err.Error()
+}
+
+func (e *proxyError) Unwrap() error {
+	return e.err
+}
+
+// proxyFailure 请求结果中代理本身的错误，目标网站的错误（5xx、验证页面、隧道建立后超时）不算代理失败
+func proxyFailure(err error, statusCode int) error {
+	if err == nil {
+		if statusCode == http.StatusProxyAuthRequired {
+			return fmt.Errorf("代理认证失败: HTTP %d", statusCode)
+		}
+		return nil
+	}
+
+	var pe *proxyError
+	if errors.As(err, &pe) {
+		return err
+	}
+	// net/http连接代理和SOCKS5握手的错误
+	var opErr *net.OpError
+	if errors.As(err, &opErr) && (opErr.Op == "proxyconnect" || strings.HasPrefix(opErr.Op, "socks ")) {
+		return err
+	}
+	return nil
+}
+
+// Healthy 可用的代理
+func (p *ProxyPool) Healthy() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var names []string
+	for _, state := range p.proxies {
+		if state.healthy {
+			names = append(names, state.name)
+		}
+	}
+	return names
+}
+
+// Start 启动定时健康检查
+func (p *ProxyPool) Start() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.stop != nil {
+		return
+	}
+	p.stop = make(chan struct{})
+
+	go func(stop chan struct{}) {
+		ticker := time.NewTicker(p.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				p.Check()
+			}
+		}
+	}(p.stop)
+}
+
+// Stop 停止健康检查
+func (p *ProxyPool) Stop() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.stop != nil {
+		close(p.stop)
+		p.stop = nil
+	}
+}
+
+// Check 通过每个代理请求检查地址（收到任何HTTP响应即视为可用），更新代理的可用状态
+func (p *ProxyPool) Check() {
+	p.mu.Lock()
+	states := append([]*proxyState(nil), p.proxies...)
+	p.mu.Unlock()
+
+	for _, state := range states {
+		err := checkProxy(state.url, p.checkURL)
+
+		p.mu.Lock()
+		switch {
+		case err == nil && !state.healthy:
+			log.Info("代理%s已恢复，重新加入代理池", state.name)
+			state.healthy = true
+			state.failures = 0
+		case err == nil:
+			state.failures = 0
+		case state.healthy:
+			log.Warn("代理%s健康检查失败，已移出代理池: %v", state.name, err)
+			state.healthy = false
+		}
+		p.mu.Unlock()
+	}
+}
+
+// checkProxy 通过代理请求检查地址
+func checkProxy(proxyURL *url.URL, checkURL string) error {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = http.ProxyURL(proxyURL)
+	defer transport.CloseIdleConnections()
+
+	c := &http.Client{Transport: transport, Timeout: 10 * time.Second}
+	resp, err := c.Get(checkURL)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode == http.StatusProxyAuthRequired {
+		return fmt.Errorf("代理需要认证（HTTP %d）", resp.StatusCode)
+	}
+	return nil
+}
+
+// newProxyTransport 按请求context中的代理发送请求的传输
+func newProxyTransport() *http.Transport {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = contextProxy
+	transport.OnProxyConnectResponse = func(_ context.Context, _ *url.URL, _ *http.Request, resp *http.Response) error {
+		if resp.StatusCode != http.StatusOK {
+			return &proxyError{err: fmt.Errorf("代理CONNECT失败: %s", resp.Status)}
+		}
+		return nil
+	}
+	return transport
+}
+
+// contextProxy 请求context中保存的代理，没有时直连
+func contextProxy(req *http.Request) (*url.URL, error) {
+	if state, ok := req.Context().Value(proxyKey{}).(*proxyState); ok {
+		return state.url, nil
+	}
+	return nil, nil
+}
+
+// dialProxy 通过代理连接addr（host:port），错误都是代理本身的错误
+func dialProxy(ctx context.Context, dialer *net.Dialer, proxyURL *url.URL, addr string) (net.Conn, error) {
+	conn, err := dialTunnel(ctx, dialer, proxyURL, addr)
+	if err != nil {
+		return nil, &proxyError{err: err}
+	}
+	return conn, nil
+}
+
+// dialTunnel 建立到addr的隧道：socks5使用SOCKS5协议，http/https代理使用CONNECT
+func dialTunnel(ctx context.Context, dialer *net.Dialer, proxyURL *url.URL, addr string) (net.Conn, error) {
+	if proxyURL.Scheme == "socks5" || proxyURL.Scheme == "socks5h" {
+		var auth *proxy.Auth
+		if proxyURL.User != nil {
+			password, _ := proxyURL.User.Password()
+			auth = &proxy.Auth{User: proxyURL.User.Username(), Password: password}
+		}
+		socks, err := proxy.SOCKS5("tcp", proxyHostPort(proxyURL), auth, dialer)
+		if err != nil {
+			return nil, err
+		}
+		return socks.(proxy.ContextDialer).DialContext(ctx, "tcp", addr)
+	}
+
+	conn, err := dialer.DialContext(ctx, "tcp", proxyHostPort(proxyURL))
+	if err != nil {
+		return nil, err
+	}
+	if proxyURL.Scheme == "https" {
+		tlsConn := tls.Client(conn, &tls.Config{ServerName: proxyURL.Hostname()})
+		if err := tlsConn.HandshakeContext(ctx); err != nil {
+			conn.Close()
+			return nil, err
+		}
+		conn = tlsConn
+	}
+
+	// 建立CONNECT隧道
+	req := "CONNECT " + addr + " HTTP/1.1\r\nHost: " + addr + "\r\n"
+	if proxyURL.User != nil {
+		password, _ := proxyURL.User.Password()
+		credentials := base64.StdEncoding.EncodeToString([]byte(proxyURL.User.Username() + ":" + password))
+		req += "Proxy-Authorization: Basic " + credentials + "\r\n"
+	}
+	req += "\r\n"
+
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	if _, err := conn.Write([]byte(req)); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	br := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(br, &http.Request{Method: http.MethodConnect})
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		conn.Close()
+		return nil, fmt.Errorf("代理CONNECT失败: %s", resp.Status)
+	}
+	if br.Buffered() > 0 {
+		conn.Close()
+		return nil, fmt.Errorf("代理CONNECT响应后有多余数据")
+	}
+	return conn, nil
+}
+
+// proxyHostPort 代理的host:port，未指定端口时使用协议的默认端口
+func proxyHostPort(proxyURL *url.URL) string {
+	if proxyURL.Port() != "" {
+		return proxyURL.Host
+	}
+	port := map[string]string{"http": "80", "https": "443", "socks5": "1080", "socks5h": "1080"}[proxyURL.Scheme]
+	return net.JoinHostPort(proxyURL.Hostname(), port)
+}
@@ -0,0 +1,311 @@
+package http
+
+import (
+	"encoding/binary"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// forwardProxy HTTP代理：普通请求直接返回代理名称，CONNECT请求建立到目标的隧道
+func forwardProxy(t *testing.T, name string) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodConnect {
+			io.WriteString(w, name)
+			return
+		}
+		target, err := net.Dial("tcp", r.Host)
+		if err != nil {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		conn, _, _ := w.(http.Hijacker).Hijack()
+		io.WriteString(conn, "HTTP/1.1 200 Connection established\r\n\r\n")
+		pipe(conn, target)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// socks5Proxy 只支持无认证CONNECT的SOCKS5代理
+func socks5Proxy(t *testing.T) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				buf := make([]byte, 262)
+				// 协商认证方式：版本、方法数量、方法列表
+				io.ReadFull(conn, buf[:2])
+				io.ReadFull(conn, buf[:buf[1]])
+				conn.Write([]byte{5, 0})
+
+				// 请求：版本、命令、保留、地址类型
+				io.ReadFull(conn, buf[:4])
+				var host string
+				switch buf[3] {
+				case 1:
+					io.ReadFull(conn, buf[:4])
+					host = net.IP(buf[:4]).String()
+				case 3:
+					io.ReadFull(conn, buf[:1])
+					n := int(buf[0])
+					io.ReadFull(conn, buf[:n])
+					host = string(buf[:n])
+				}
+				io.ReadFull(conn, buf[:2])
+				port := binary.BigEndian.Uint16(buf[:2])
+
+				target, err := net.Dial("tcp", net.JoinHostPort(host, strconv.Itoa(int(port))))
+				if err != nil {
+					conn.Write([]byte{5, 1, 0, 1, 0, 0, 0, 0, 0, 0})
+					conn.Close()
+					return
+				}
+				conn.Write([]byte{5, 0, 0, 1, 0, 0, 0, 0, 0, 0})
+				pipe(conn, target)
+			}()
+		}
+	}()
+	return "socks5://" + ln.Addr().String()
+}
+
+// pipe 双向转发数据
+func pipe(a, b net.Conn) {
+	go func() {
+		io.Copy(a, b)
+		a.Close()
+	}()
+	io.Copy(b, a)
+	b.Close()
+}
+
+// deadProxy 已关闭的代理地址
+func deadProxy(t *testing.T) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := ln.Addr().String()
+	ln.Close()
+	return "http://" + addr
+}
+
+func TestProxyRotation(t *testing.T) {
+	loadConfig(t, `
+http:
+  timeout: 5
+  proxyCheck:
+    maxFailures: 1
+`)
+	p1 := forwardProxy(t, "proxy1")
+	p2 := forwardProxy(t, "proxy2")
+	dead := deadProxy(t)
+
+	waits := recordSleep(t)
+	c, err := NewClient(Options{Proxies: []string{p1.URL, dead, p2.URL}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.proxies.Stop()
+
+	var got []string
+	for i := 0; i < 5; i++ {
+		body, err := c.GetBody("http://origin.test/", nil, "")
+		if err != nil {
+			t.Fatalf("GetBody: %v", err)
+		}
+		got = append(got, string(body))
+	}
+
+	// 失败的请求换下一个代理重试，失败的代理移出轮换后只使用剩下的代理
+	if want := "proxy1,proxy2,proxy1,proxy2,proxy1"; strings.Join(got, ",") != want {
+		t.Errorf("rotation = %s, want %s", strings.Join(got, ","), want)
+	}
+	if len(*waits) != 1 {
+		t.Errorf("retries = %d, want 1", len(*waits))
+	}
+	if healthy := c.proxies.Healthy(); len(healthy) != 2 {
+		t.Errorf("healthy proxies = %v, want 2", healthy)
+	}
+
+}
+
+func TestProxyErrorNamesProxy(t *testing.T) {
+	loadConfig(t, `
+http:
+  timeout: 5
+  retry:
+    max: 0
+`)
+	dead := deadProxy(t)
+
+	c, err := NewClient(Options{Proxies: []string{dead}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.proxies.Stop()
+
+	// 不重试时错误信息注明代理
+	if _, err := c.GetBody("http://origin.test/", nil, ""); err == nil || !strings.Contains(err.Error(), dead) {
+		t.Errorf("error should name the proxy: %v", err)
+	}
+}
+
+func TestProxyTunnel(t *testing.T) {
+	origin := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "origin")
+	}))
+	defer origin.Close()
+
+	loadConfig(t, `
+http:
+  timeout: 5
+  profiles:
+    - name: ordered
+      userAgent: test-ua
+      headerOrder: [user-agent, accept]
+`)
+
+	for _, proxyURL := range []string{socks5Proxy(t), forwardProxy(t, "proxy").URL} {
+		for _, profile := range []string{DefaultProfile, "ordered"} {
+			c, err := NewClient(Options{Profile: profile, Proxies: []string{proxyURL}})
+			if err != nil {
+				t.Fatal(err)
+			}
+			body, err := c.GetBody(origin.URL, nil, "")
+			c.proxies.Stop()
+			if err != nil {
+				t.Errorf("%s via %s: %v", profile, proxyURL, err)
+				continue
+			}
+			// http代理的普通请求由代理直接返回，CONNECT隧道和socks5到达源服务器
+			want := "origin"
+			if profile == DefaultProfile && strings.HasPrefix(proxyURL, "http://") {
+				want = "proxy"
+			}
+			if string(body) != want {
+				t.Errorf("%s via %s: body = %q, want %q", profile, proxyURL, body, want)
+			}
+		}
+	}
+}
+
+func TestProxyTargetFailures(t *testing.T) {
+	origin := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer origin.Close()
+
+	loadConfig(t, `
+http:
+  timeout: 5
+  retry:
+    max: 0
+  proxyCheck:
+    maxFailures: 1
+  profiles:
+    - name: ordered
+      userAgent: test-ua
+      headerOrder: [user-agent, accept]
+`)
+
+	// 目标网站的错误不计入代理的健康状态
+	for _, proxyURL := range []string{socks5Proxy(t), forwardProxy(t, "proxy").URL} {
+		for _, profile := range []string{DefaultProfile, "ordered"} {
+			c, err := NewClient(Options{Profile: profile, Proxies: []string{proxyURL}})
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, err = c.GetBody(origin.URL, nil, "")
+			healthy := c.proxies.Healthy()
+			c.proxies.Stop()
+			if KindOf(err) != KindHTTP5xx && !(profile == DefaultProfile && strings.HasPrefix(proxyURL, "http://")) {
+				t.Errorf("%s via %s: error = %v, want HTTP 502", profile, proxyURL, err)
+			}
+			if len(healthy) != 1 {
+				t.Errorf("%s via %s: proxy removed after target failure", profile, proxyURL)
+			}
+		}
+	}
+
+	// CONNECT被代理拒绝计入代理失败
+	for _, profile := range []string{DefaultProfile, "ordered"} {
+		c, err := NewClient(Options{Profile: profile, Proxies: []string{forwardProxy(t, "proxy").URL}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = c.GetBody("https://"+deadProxy(t)[len("http://"):]+"/", nil, "")
+		healthy := c.proxies.Healthy()
+		c.proxies.Stop()
+		if err == nil || len(healthy) != 0 {
+			t.Errorf("%s: CONNECT failure err = %v, healthy = %v", profile, err, healthy)
+		}
+	}
+}
+
+func TestProxyFailure(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		status int
+		want   bool
+	}{
+		{"ok", nil, http.StatusOK, false},
+		{"origin 5xx", nil, http.StatusBadGateway, false},
+		{"proxy auth", nil, http.StatusProxyAuthRequired, true},
+		{"dial", &proxyError{err: io.EOF}, 0, true},
+		{"proxyconnect", &net.OpError{Op: "proxyconnect", Err: io.EOF}, 0, true},
+		{"socks", &net.OpError{Op: "socks connect", Err: io.EOF}, 0, true},
+		{"origin", &net.OpError{Op: "read", Err: io.EOF}, 0, false},
+	}
+	for _, tt := range tests {
+		if got := proxyFailure(tt.err, tt.status) != nil; got != tt.want {
+			t.Errorf("%s: proxyFailure = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestProxyCheck(t *testing.T) {
+	origin := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer origin.Close()
+
+	loadConfig(t, `
+http:
+  proxyCheck:
+    url: `+origin.URL+`
+`)
+	dead := deadProxy(t)
+	pool, err := NewProxyPool([]string{"direct", dead})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pool.Check()
+	if healthy := pool.Healthy(); len(healthy) != 1 || healthy[0] != "direct" {
+		t.Errorf("healthy after check = %v, want [direct]", healthy)
+	}
+
+	for _, raw := range []string{"ftp://127.0.0.1:21", "http://", "://bad"} {
+		if _, err := NewProxyPool([]string{raw}); err == nil {
+			t.Errorf("NewProxyPool(%q) should fail", raw)
+		}
+	}
+}
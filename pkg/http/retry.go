@@ -0,0 +1,86 @@
+package http
+
+import (
+	"math/rand/v2"
+	"net/http"
+	"time"
+
+	"iptv/pkg/config"
+)
+
+const (
+	defaultRetries   = 2  // 默认最多重试次数
+	defaultBaseDelay = 1  // 默认第一次重试前的等待时间（秒）
+	defaultMaxDelay  = 30 // 默认最长等待时间（秒）
+)
+
+// sleep 重试前等待，测试时替换
+var sleep = time.Sleep
+
+// retryPolicy 重试策略
+type retryPolicy struct {
+	max      int           // 最多重试次数，0表示不重试
+	base     time.Duration // 第一次重试前的等待时间，之后每次翻倍
+	maxDelay time.Duration // 最长等待时间，Retry-After超过该值时不再重试
+	unsafe   bool          // POST等非幂等请求也重试
+}
+
+// loadRetryPolicy 读取 http.retry 配置
+func loadRetryPolicy() retryPolicy {
+	p := retryPolicy{
+		max:      defaultRetries,
+		base:     defaultBaseDelay * time.Second,
+		maxDelay: defaultMaxDelay * time.Second,
+	}
+	cfg := config.GetConfig()
+	if cfg == nil {
+		return p
+	}
+
+	retry := cfg.HTTP.Retry
+	if retry.Max != nil && *retry.Max >= 0 {
+		p.max = *retry.Max
+	}
+	if retry.BaseDelay > 0 {
+		p.base = time.Duration(retry.BaseDelay * float64(time.Second))
+	}
+	if retry.MaxDelay > 0 {
+		p.maxDelay = time.Duration(retry.MaxDelay * float64(time.Second))
+	}
+	if p.base > p.maxDelay {
+		p.base = p.maxDelay
+	}
+	return p
+}
+
+// delay 第attempt次请求失败后的等待时间，false表示不重试
+// 429/503响应的Retry-After优先，其他情况使用带随机抖动的指数退避
+func (p retryPolicy) delay(err *Error, attempt int) (time.Duration, bool) {
+	if attempt > p.max || !retryable(err) || !(p.unsafe || idempotent(err.Method)) {
+		return 0, false
+	}
+	if err.RetryAfter > 0 {
+		if err.RetryAfter > p.maxDelay {
+			return 0, false
+		}
+		return err.RetryAfter, true
+	}
+	return p.backoff(attempt), true
+}
+
+// idempotent 请求是否可以安全地重复发送，只有GET和HEAD默认重试
+func idempotent(method string) bool {
+	return method == http.MethodGet || method == http.MethodHead
+}
+
+// backoff 指数退避：base*2^(attempt-1)，不超过maxDelay，实际等待时间在其一半到全部之间随机
+func (p retryPolicy) backoff(attempt int) time.Duration {
+	d := p.maxDelay
+	if attempt < 32 {
+		if exp := p.base << (attempt - 1); exp > 0 && exp < d {
+			d = exp
+		}
+	}
+	half := d / 2
+	return half + rand.N(d-half+1)
+}
@@ -0,0 +1,235 @@
+package http
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// recordSleep 记录重试前的等待时间，不实际等待
+func recordSleep(t *testing.T) *[]time.Duration {
+	t.Helper()
+	var waits []time.Duration
+	sleep = func(d time.Duration) { waits = append(waits, d) }
+	t.Cleanup(func() { sleep = time.Sleep })
+	return &waits
+}
+
+func TestRetry(t *testing.T) {
+	loadConfig(t, `
+http:
+  timeout: 5
+  retry:
+    max: 2
+    baseDelay: 1
+    maxDelay: 10
+`)
+
+	tests := []struct {
+		name      string
+		handler   func(n int32, w http.ResponseWriter)
+		wantCalls int32
+		wantKind  ErrorKind // 为空表示成功
+		wantWaits []time.Duration
+	}{
+		{
+			name: "503 with Retry-After then success",
+			handler: func(n int32, w http.ResponseWriter) {
+				if n < 3 {
+					w.Header().Set("Retry-After", "2")
+					w.WriteHeader(http.StatusServiceUnavailable)
+					return
+				}
+				fmt.Fprint(w, "ok")
+			},
+			wantCalls: 3,
+			wantWaits: []time.Duration{2 * time.Second, 2 * time.Second},
+		},
+		{
+			name: "404 is not retried",
+			handler: func(n int32, w http.ResponseWriter) {
+				w.WriteHeader(http.StatusNotFound)
+			},
+			wantCalls: 1,
+			wantKind:  KindHTTP4xx,
+		},
+		{
+			name: "429 with long Retry-After gives up",
+			handler: func(n int32, w http.ResponseWriter) {
+				w.Header().Set("Retry-After", "3600")
+				w.WriteHeader(http.StatusTooManyRequests)
+			},
+			wantCalls: 1,
+			wantKind:  KindHTTP4xx,
+		},
+		{
+			name: "500 retried until max",
+			handler: func(n int32, w http.ResponseWriter) {
+				w.WriteHeader(http.StatusInternalServerError)
+			},
+			wantCalls: 3,
+			wantKind:  KindHTTP5xx,
+		},
+		{
+			name: "challenge is not retried",
+			handler: func(n int32, w http.ResponseWriter) {
+				w.WriteHeader(http.StatusForbidden)
+				fmt.Fprint(w, "<title>Just a moment...</title>")
+			},
+			wantCalls: 1,
+			wantKind:  KindChallenge,
+		},
+	}
+
+	for _, tt := range tests {
+		var calls atomic.Int32
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			tt.handler(calls.Add(1), w)
+		}))
+		waits := recordSleep(t)
+
+		c, err := NewClient(Options{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = c.Get(srv.URL, nil, "")
+		srv.Close()
+
+		if got := calls.Load(); got != tt.wantCalls {
+			t.Errorf("%s: calls = %d, want %d", tt.name, got, tt.wantCalls)
+		}
+		if tt.wantKind == "" {
+			if err != nil {
+				t.Errorf("%s: unexpected error %v", tt.name, err)
+			}
+		} else if kind := KindOf(err); kind != tt.wantKind {
+			t.Errorf("%s: kind = %s, want %s (%v)", tt.name, kind, tt.wantKind, err)
+		}
+		if tt.wantWaits != nil && fmt.Sprint(*waits) != fmt.Sprint(tt.wantWaits) {
+			t.Errorf("%s: waits = %v, want %v", tt.name, *waits, tt.wantWaits)
+		}
+		if tt.wantKind == KindChallenge && !errors.Is(err, ErrChallenge) {
+			t.Errorf("%s: error should wrap ErrChallenge: %v", tt.name, err)
+		}
+	}
+}
+
+func TestRetryPost(t *testing.T) {
+	loadConfig(t, `
+http:
+  timeout: 5
+  retry:
+    max: 2
+`)
+
+	// POST默认不重试，调用方允许时才重试
+	for _, tt := range []struct {
+		unsafe    bool
+		wantCalls int32
+	}{{false, 1}, {true, 3}} {
+		var calls atomic.Int32
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			calls.Add(1)
+			w.WriteHeader(http.StatusBadGateway)
+		}))
+		recordSleep(t)
+
+		c, err := NewClient(Options{RetryUnsafe: tt.unsafe})
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = c.Post(srv.URL, "a=1", nil, "")
+		srv.Close()
+
+		if KindOf(err) != KindHTTP5xx {
+			t.Errorf("unsafe=%v: kind = %s (%v)", tt.unsafe, KindOf(err), err)
+		}
+		if got := calls.Load(); got != tt.wantCalls {
+			t.Errorf("unsafe=%v: calls = %d, want %d", tt.unsafe, got, tt.wantCalls)
+		}
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	p := retryPolicy{max: 10, base: time.Second, maxDelay: 10 * time.Second}
+	for attempt, want := range map[int]time.Duration{1: time.Second, 2: 2 * time.Second, 3: 4 * time.Second, 5: 10 * time.Second, 40: 10 * time.Second} {
+		for i := 0; i < 20; i++ {
+			if d := p.backoff(attempt); d < want/2 || d > want {
+				t.Errorf("backoff(%d) = %v, want between %v and %v", attempt, d, want/2, want)
+			}
+		}
+	}
+
+	if _, ok := p.delay(&Error{Kind: KindTimeout, Method: http.MethodGet}, 11); ok {
+		t.Error("delay should stop after max retries")
+	}
+	if _, ok := p.delay(&Error{Kind: KindNetwork, Method: http.MethodGet, Err: ErrNoProxy}, 1); ok {
+		t.Error("no available proxy should not be retried")
+	}
+	if _, ok := p.delay(&Error{Kind: KindTimeout, Method: http.MethodPost}, 1); ok {
+		t.Error("POST should not be retried by default")
+	}
+	p.unsafe = true
+	if _, ok := p.delay(&Error{Kind: KindTimeout, Method: http.MethodPost}, 1); !ok {
+		t.Error("POST should be retried when allowed")
+	}
+}
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		err  error
+		want ErrorKind
+	}{
+		{&net.DNSError{Err: "no such host", Name: "example.invalid", IsNotFound: true}, KindDNS},
+		{fmt.Errorf("Get: %w", context.DeadlineExceeded), KindTimeout},
+		{&net.OpError{Op: "dial", Err: timeoutError{}}, KindTimeout},
+		{tls.RecordHeaderError{Msg: "first record does not look like a TLS handshake"}, KindTLS},
+		{&tls.CertificateVerificationError{Err: x509.UnknownAuthorityError{}}, KindTLS},
+		{&net.OpError{Op: "dial", Err: errors.New("connect: connection refused")}, KindNetwork},
+	}
+	for _, tt := range tests {
+		if got := classify(tt.err); got != tt.want {
+			t.Errorf("classify(%v) = %s, want %s", tt.err, got, tt.want)
+		}
+	}
+
+	if got := KindOf(errors.New("解析失败")); got != KindOther {
+		t.Errorf("KindOf(plain error) = %s, want %s", got, KindOther)
+	}
+	wrapped := fmt.Errorf("获取频道失败: %w", &Error{Kind: KindHTTP5xx, StatusCode: 502})
+	if got := KindOf(wrapped); got != KindHTTP5xx {
+		t.Errorf("KindOf(wrapped) = %s, want %s", got, KindHTTP5xx)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	tests := map[string]time.Duration{
+		"":                              0,
+		"5":                             5 * time.Second,
+		"-1":                            0,
+		"Wed, 01 Jan 2025 00:01:00 GMT": time.Minute,
+		"Tue, 31 Dec 2024 00:00:00 GMT": 0,
+		"soon":                          0,
+	}
+	for value, want := range tests {
+		if got := parseRetryAfter(value, now); got != want {
+			t.Errorf("parseRetryAfter(%q) = %v, want %v", value, got, want)
+		}
+	}
+}
+
+// timeoutError 超时的网络错误
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "i/o timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
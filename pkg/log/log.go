@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sync"
 	"time"
 
 	"iptv/pkg/bark"
@@ -14,8 +15,12 @@ var (
 	logFile   *os.File
 	logDir    = "logs"
 	logPrefix = "app"
+	pushes    sync.WaitGroup // 未完成的ERROR日志推送
 )
 
+// pushTimeout 关闭日志时等待推送完成的最长时间
+const pushTimeout = 10 * time.Second
+
 // Init 初始化日志系统
 func Init() error {
 	// 从配置读取日志路径
@@ -44,8 +49,18 @@ func Init() error {
 	return nil
 }
 
-// Close 关闭日志文件
+// Close 等待未完成的推送（最多pushTimeout）后关闭日志文件，退出程序前调用，避免ERROR推送丢失
 func Close() {
+	done := make(chan struct{})
+	go func() {
+		pushes.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(pushTimeout):
+	}
+
 	if logFile != nil {
 		logFile.Close()
 	}
@@ -53,17 +68,16 @@ func Close() {
 
 // writeLog 写入日志（内部函数）
 func writeLog(level string, format string, args ...interface{}) {
-	if logFile == nil {
-		// 如果未初始化，尝试初始化
-		if err := Init(); err != nil {
-			return
-		}
-	}
-
 	timestamp := time.Now().Format("2006-01-02 15:04:05")
 	message := fmt.Sprintf(format, args...)
 	logLine := fmt.Sprintf("[%s] [%s] %s\n", timestamp, level, message)
 
+	// 未调用Init时（例如测试）输出到标准错误，不创建日志目录
+	if logFile == nil {
+		os.Stderr.WriteString(logLine)
+		return
+	}
+
 	logFile.WriteString(logLine)
 	logFile.Sync() // 立即刷新到磁盘
 }
@@ -81,8 +95,10 @@ func Error(format string, args ...interface{}) {
 	// 推送ERROR日志到Bark
 	cfg := config.GetConfig()
 	if cfg != nil && cfg.Push.Bark.Host != "" && cfg.Push.Bark.Key != "" {
-		// 异步推送，不阻塞日志写入
+		// 异步推送，不阻塞日志写入；Close时等待推送完成
+		pushes.Add(1)
 		go func() {
+			defer pushes.Done()
 			bark.Push("IPTV错误", "%s", message)
 		}()
 	}
@@ -0,0 +1,91 @@
+package mpegts
+
+import "errors"
+
+var errShortData = errors.New("数据不足")
+
+// bitReader 按位读取RBSP数据（支持指数哥伦布编码）
+type bitReader struct {
+	data []byte
+	pos  int // 当前位偏移
+}
+
+// u 读取n位无符号整数
+func (r *bitReader) u(n int) (uint32, error) {
+	var v uint32
+	for i := 0; i < n; i++ {
+		if r.pos >= len(r.data)*8 {
+			return 0, errShortData
+		}
+		bit := (r.data[r.pos/8] >> (7 - uint(r.pos%8))) & 1
+		v = v<<1 | uint32(bit)
+		r.pos++
+	}
+	return v, nil
+}
+
+// skip 跳过n位
+func (r *bitReader) skip(n int) error {
+	if r.pos+n > len(r.data)*8 {
+		return errShortData
+	}
+	r.pos += n
+	return nil
+}
+
+// ue 读取无符号指数哥伦布编码
+func (r *bitReader) ue() (uint32, error) {
+	zeros := 0
+	for {
+		bit, err := r.u(1)
+		if err != nil {
+			return 0, err
+		}
+		if bit == 1 {
+			break
+		}
+		zeros++
+		if zeros > 31 {
+			return 0, errors.New("无效的指数哥伦布编码")
+		}
+	}
+	if zeros == 0 {
+		return 0, nil
+	}
+	v, err := r.u(zeros)
+	if err != nil {
+		return 0, err
+	}
+	return (1<<uint(zeros) - 1) + v, nil
+}
+
+// se 读取有符号指数哥伦布编码
+func (r *bitReader) se() (int32, error) {
+	v, err := r.ue()
+	if err != nil {
+		return 0, err
+	}
+	if v%2 == 1 {
+		return int32((v + 1) / 2), nil
+	}
+	return -int32(v / 2), nil
+}
+
+// unescapeRBSP 去除NAL中的防竞争字节（00 00 03）
+func unescapeRBSP(nal []byte) []byte {
+	out := make([]byte, 0, len(nal))
+	zeros := 0
+	for _, b := range nal {
+		if zeros >= 2 && b == 3 {
+			zeros = 0
+			continue
+		}
+		out = append(out, b)
+		if b == 0 {
+			zeros++
+		} else {
+			zeros = 0
+		}
+	}
+	return out
+}
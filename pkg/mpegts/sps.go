@@ -0,0 +1,389 @@
+package mpegts
+
+// videoParams 从序列参数集中解析出的视频参数
+type videoParams struct {
+	width     int
+	height    int
+	frameRate float64 // 0表示码流中未声明
+}
+
+// parseH264SPS 解析H.264 SPS（nal包含1字节NAL头）
+func parseH264SPS(nal []byte) (videoParams, error) {
+	var p videoParams
+	if len(nal) < 4 {
+		return p, errShortData
+	}
+	r := &bitReader{data: unescapeRBSP(nal[1:])}
+
+	profileIdc, err := r.u(8)
+	if err != nil {
+		return p, err
+	}
+	if err = r.skip(16); err != nil { // constraint_set_flags + level_idc
+		return p, err
+	}
+	if _, err = r.ue(); err != nil { // seq_parameter_set_id
+		return p, err
+	}
+
+	chromaFormatIdc := uint32(1)
+	separateColourPlane := uint32(0)
+	switch profileIdc {
+	case 100, 110, 122, 244, 44, 83, 86, 118, 128, 138, 139, 134, 135:
+		if chromaFormatIdc, err = r.ue(); err != nil {
+			return p, err
+		}
+		if chromaFormatIdc == 3 {
+			if separateColourPlane, err = r.u(1); err != nil {
+				return p, err
+			}
+		}
+		if _, err = r.ue(); err != nil { // bit_depth_luma_minus8
+			return p, err
+		}
+		if _, err = r.ue(); err != nil { // bit_depth_chroma_minus8
+			return p, err
+		}
+		if err = r.skip(1); err != nil { // qpprime_y_zero_transform_bypass_flag
+			return p, err
+		}
+		scalingMatrixPresent, err := r.u(1)
+		if err != nil {
+			return p, err
+		}
+		if scalingMatrixPresent == 1 {
+			count := 8
+			if chromaFormatIdc == 3 {
+				count = 12
+			}
+			for i := 0; i < count; i++ {
+				present, err := r.u(1)
+				if err != nil {
+					return p, err
+				}
+				if present == 0 {
+					continue
+				}
+				size := 16
+				if i >= 6 {
+					size = 64
+				}
+				if err = skipScalingList(r, size); err != nil {
+					return p, err
+				}
+			}
+		}
+	}
+
+	if _, err = r.ue(); err != nil { // log2_max_frame_num_minus4
+		return p, err
+	}
+	pocType, err := r.ue()
+	if err != nil {
+		return p, err
+	}
+	switch pocType {
+	case 0:
+		if _, err = r.ue(); err != nil { // log2_max_pic_order_cnt_lsb_minus4
+			return p, err
+		}
+	case 1:
+		if err = r.skip(1); err != nil { // delta_pic_order_always_zero_flag
+			return p, err
+		}
+		if _, err = r.se(); err != nil { // offset_for_non_ref_pic
+			return p, err
+		}
+		if _, err = r.se(); err != nil { // offset_for_top_to_bottom_field
+			return p, err
+		}
+		cycle, err := r.ue()
+		if err != nil {
+			return p, err
+		}
+		for i := uint32(0); i < cycle; i++ {
+			if _, err = r.se(); err != nil {
+				return p, err
+			}
+		}
+	}
+
+	if _, err = r.ue(); err != nil { // max_num_ref_frames
+		return p, err
+	}
+	if err = r.skip(1); err != nil { // gaps_in_frame_num_value_allowed_flag
+		return p, err
+	}
+	widthMbs, err := r.ue()
+	if err != nil {
+		return p, err
+	}
+	heightMapUnits, err := r.ue()
+	if err != nil {
+		return p, err
+	}
+	frameMbsOnly, err := r.u(1)
+	if err != nil {
+		return p, err
+	}
+	if frameMbsOnly == 0 {
+		if err = r.skip(1); err != nil { // mb_adaptive_frame_field_flag
+			return p, err
+		}
+	}
+	if err = r.skip(1); err != nil { // direct_8x8_inference_flag
+		return p, err
+	}
+
+	var cropLeft, cropRight, cropTop, cropBottom uint32
+	cropping, err := r.u(1)
+	if err != nil {
+		return p, err
+	}
+	if cropping == 1 {
+		for _, v := range []*uint32{&cropLeft, &cropRight, &cropTop, &cropBottom} {
+			if *v, err = r.ue(); err != nil {
+				return p, err
+			}
+		}
+	}
+
+	// 计算裁剪单位
+	cropUnitX, cropUnitY := 1, 2-int(frameMbsOnly)
+	if chromaFormatIdc != 0 && separateColourPlane == 0 {
+		subWidthC, subHeightC := 2, 2
+		switch chromaFormatIdc {
+		case 2:
+			subHeightC = 1
+		case 3:
+			subWidthC, subHeightC = 1, 1
+		}
+		cropUnitX = subWidthC
+		cropUnitY = subHeightC * (2 - int(frameMbsOnly))
+	}
+
+	p.width = int(widthMbs+1)*16 - cropUnitX*int(cropLeft+cropRight)
+	p.height = (2-int(frameMbsOnly))*int(heightMapUnits+1)*16 - cropUnitY*int(cropTop+cropBottom)
+
+	// VUI中的帧率信息（解析失败不影响分辨率）
+	vuiPresent, err := r.u(1)
+	if err != nil || vuiPresent == 0 {
+		return p, nil
+	}
+	if rate, err := parseH264VUITiming(r); err == nil {
+		p.frameRate = rate
+	}
+
+	return p, nil
+}
+
+// skipScalingList 跳过缩放矩阵
+func skipScalingList(r *bitReader, size int) error {
+	last, next := int32(8), int32(8)
+	for j := 0; j < size; j++ {
+		if next != 0 {
+			delta, err := r.se()
+			if err != nil {
+				return err
+			}
+			next = (last + delta + 256) % 256
+		}
+		if next != 0 {
+			last = next
+		}
+	}
+	return nil
+}
+
+// parseH264VUITiming 解析VUI直到timing_info，返回帧率
+func parseH264VUITiming(r *bitReader) (float64, error) {
+	aspectPresent, err := r.u(1)
+	if err != nil {
+		return 0, err
+	}
+	if aspectPresent == 1 {
+		idc, err := r.u(8)
+		if err != nil {
+			return 0, err
+		}
+		if idc == 255 { // Extended_SAR
+			if err = r.skip(32); err != nil {
+				return 0, err
+			}
+		}
+	}
+
+	overscanPresent, err := r.u(1)
+	if err != nil {
+		return 0, err
+	}
+	if overscanPresent == 1 {
+		if err = r.skip(1); err != nil {
+			return 0, err
+		}
+	}
+
+	signalTypePresent, err := r.u(1)
+	if err != nil {
+		return 0, err
+	}
+	if signalTypePresent == 1 {
+		if err = r.skip(4); err != nil { // video_format + video_full_range_flag
+			return 0, err
+		}
+		colourPresent, err := r.u(1)
+		if err != nil {
+			return 0, err
+		}
+		if colourPresent == 1 {
+			if err = r.skip(24); err != nil {
+				return 0, err
+			}
+		}
+	}
+
+	chromaLocPresent, err := r.u(1)
+	if err != nil {
+		return 0, err
+	}
+	if chromaLocPresent == 1 {
+		if _, err = r.ue(); err != nil {
+			return 0, err
+		}
+		if _, err = r.ue(); err != nil {
+			return 0, err
+		}
+	}
+
+	timingPresent, err := r.u(1)
+	if err != nil || timingPresent == 0 {
+		return 0, err
+	}
+	unitsInTick, err := r.u(32)
+	if err != nil {
+		return 0, err
+	}
+	timeScale, err := r.u(32)
+	if err != nil {
+		return 0, err
+	}
+	if unitsInTick == 0 {
+		return 0, nil
+	}
+	return float64(timeScale) / float64(2*unitsInTick), nil
+}
+
+// parseHEVCSPS 解析HEVC SPS中的分辨率（nal包含2字节NAL头）
+func parseHEVCSPS(nal []byte) (videoParams, error) {
+	var p videoParams
+	if len(nal) < 4 {
+		return p, errShortData
+	}
+	r := &bitReader{data: unescapeRBSP(nal[2:])}
+
+	if err := r.skip(4); err != nil { // sps_video_parameter_set_id
+		return p, err
+	}
+	maxSubLayersMinus1, err := r.u(3)
+	if err != nil {
+		return p, err
+	}
+	if err = r.skip(1); err != nil { // sps_temporal_id_nesting_flag
+		return p, err
+	}
+
+	// profile_tier_level
+	if err = r.skip(2 + 1 + 5 + 32 + 48 + 8); err != nil {
+		return p, err
+	}
+	profilePresent := make([]uint32, maxSubLayersMinus1)
+	levelPresent := make([]uint32, maxSubLayersMinus1)
+	for i := range profilePresent {
+		if profilePresent[i], err = r.u(1); err != nil {
+			return p, err
+		}
+		if levelPresent[i], err = r.u(1); err != nil {
+			return p, err
+		}
+	}
+	if maxSubLayersMinus1 > 0 {
+		if err = r.skip(2 * int(8-maxSubLayersMinus1)); err != nil {
+			return p, err
+		}
+	}
+	for i := range profilePresent {
+		if profilePresent[i] == 1 {
+			if err = r.skip(88); err != nil {
+				return p, err
+			}
+		}
+		if levelPresent[i] == 1 {
+			if err = r.skip(8); err != nil {
+				return p, err
+			}
+		}
+	}
+
+	if _, err = r.ue(); err != nil { // sps_seq_parameter_set_id
+		return p, err
+	}
+	chromaFormatIdc, err := r.ue()
+	if err != nil {
+		return p, err
+	}
+	if chromaFormatIdc == 3 {
+		if err = r.skip(1); err != nil { // separate_colour_plane_flag
+			return p, err
+		}
+	}
+	width, err := r.ue()
+	if err != nil {
+		return p, err
+	}
+	height, err := r.ue()
+	if err != nil {
+		return p, err
+	}
+
+	var left, right, top, bottom uint32
+	conformance, err := r.u(1)
+	if err != nil {
+		return p, err
+	}
+	if conformance == 1 {
+		for _, v := range []*uint32{&left, &right, &top, &bottom} {
+			if *v, err = r.ue(); err != nil {
+				return p, err
+			}
+		}
+	}
+
+	subWidthC, subHeightC := 1, 1
+	switch chromaFormatIdc {
+	case 1:
+		subWidthC, subHeightC = 2, 2
+	case 2:
+		subWidthC = 2
+	}
+
+	p.width = int(width) - subWidthC*int(left+right)
+	p.height = int(height) - subHeightC*int(top+bottom)
+	return p, nil
+}
+
+// MPEG-2 frame_rate_code对应的帧率
+var mpeg2FrameRates = []float64{0, 24000.0 / 1001, 24, 25, 30000.0 / 1001, 30, 50, 60000.0 / 1001, 60}
+
+// parseMPEG2SequenceHeader 解析MPEG-2序列头（data从00 00 01 B3之后开始）
+func parseMPEG2SequenceHeader(data []byte) (videoParams, error) {
+	var p videoParams
+	if len(data) < 4 {
+		return p, errShortData
+	}
+	p.width = int(data[0])<<4 | int(data[1])>>4
+	p.height = int(data[1]&0x0f)<<8 | int(data[2])
+	if code := int(data[3] & 0x0f); code < len(mpeg2FrameRates) {
+		p.frameRate = mpeg2FrameRates[code]
+	}
+	return p, nil
+}
@@ -0,0 +1,412 @@
+package mpegts
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+)
+
+// PacketSize TS包大小
+const PacketSize = 188
+
+const syncByte = 0x47
+
+// 编码名称
+const (
+	CodecH264  = "h264"
+	CodecHEVC  = "hevc"
+	CodecMPEG1 = "mpeg1"
+	CodecMPEG2 = "mpeg2"
+	CodecAAC   = "aac"
+	CodecMP2   = "mp2"
+	CodecAC3   = "ac3"
+	CodecEAC3  = "eac3"
+)
+
+// 单路视频最多缓存多少PES数据用于查找SPS
+const maxVideoBuffer = 512 * 1024
+
+// Info TS流检测结果
+type Info struct {
+	VideoCodec string
+	AudioCodec string
+	Width      int
+	Height     int
+	FrameRate  float64
+}
+
+// complete 是否已获得全部信息（帧率来自码流声明）
+func (i *Info) complete() bool {
+	return i.VideoCodec != "" && i.AudioCodec != "" && i.Width > 0 && i.FrameRate > 0
+}
+
+// ErrNotTS 数据不是MPEG-TS
+var ErrNotTS = errors.New("不是有效的MPEG-TS数据")
+
+// inspector 解析状态
+type inspector struct {
+	info     Info
+	pmtPIDs  map[uint16]bool
+	sections map[uint16][]byte // PSI分段缓存
+	videoPID int
+	audioPID int
+	video    []byte   // 当前视频PES负载
+	pts      []uint64 // 视频PES时间戳
+	gotSPS   bool
+}
+
+// Inspect 读取最多maxPackets个TS包，解析PAT/PMT和视频参数
+func Inspect(r io.Reader, maxPackets int) (*Info, error) {
+	br := bufio.NewReaderSize(r, PacketSize*64)
+	if err := syncStream(br); err != nil {
+		return nil, err
+	}
+
+	ins := &inspector{
+		pmtPIDs:  make(map[uint16]bool),
+		sections: make(map[uint16][]byte),
+		videoPID: -1,
+		audioPID: -1,
+	}
+
+	packet := make([]byte, PacketSize)
+	var readErr error
+	for i := 0; i < maxPackets; i++ {
+		if _, readErr = io.ReadFull(br, packet); readErr != nil {
+			break
+		}
+		if packet[0] != syncByte {
+			// 失去同步，重新查找包头
+			if readErr = syncStream(br); readErr != nil {
+				break
+			}
+			continue
+		}
+		ins.handlePacket(packet)
+		if ins.done() {
+			break
+		}
+	}
+
+	ins.finish()
+	if ins.info.VideoCodec == "" && ins.info.AudioCodec == "" {
+		if readErr != nil && !errors.Is(readErr, io.EOF) && !errors.Is(readErr, io.ErrUnexpectedEOF) {
+			return nil, fmt.Errorf("读取TS数据失败: %v", readErr)
+		}
+		return nil, fmt.Errorf("未找到PMT")
+	}
+	return &ins.info, nil
+}
+
+// InspectBytes 检测内存中的TS数据
+func InspectBytes(data []byte) (*Info, error) {
+	return Inspect(bytes.NewReader(data), len(data)/PacketSize+1)
+}
+
+// syncStream 跳过数据直到连续出现三个同步字节
+func syncStream(br *bufio.Reader) error {
+	for skipped := 0; skipped < PacketSize*16; skipped++ {
+		head, err := br.Peek(PacketSize*2 + 1)
+		if err != nil {
+			if len(head) > 0 && head[0] == syncByte && len(head) < PacketSize*2+1 {
+				return nil
+			}
+			return ErrNotTS
+		}
+		if head[0] == syncByte && head[PacketSize] == syncByte && head[PacketSize*2] == syncByte {
+			return nil
+		}
+		if _, err = br.Discard(1); err != nil {
+			return err
+		}
+	}
+	return ErrNotTS
+}
+
+// done 是否可以提前结束（未声明帧率时需要收集足够的PTS用于估算）
+func (ins *inspector) done() bool {
+	if ins.info.complete() {
+		return true
+	}
+	return ins.info.VideoCodec != "" && ins.info.AudioCodec != "" && ins.info.Width > 0 && len(ins.pts) >= 16
+}
+
+// handlePacket 处理单个TS包
+func (ins *inspector) handlePacket(packet []byte) {
+	pid := uint16(packet[1]&0x1f)<<8 | uint16(packet[2])
+	unitStart := packet[1]&0x40 != 0
+	adaptation := (packet[3] >> 4) & 0x03
+
+	offset := 4
+	if adaptation == 2 || adaptation == 0 {
+		return // 没有负载
+	}
+	if adaptation == 3 {
+		offset += 1 + int(packet[4])
+	}
+	if offset >= PacketSize {
+		return
+	}
+	payload := packet[offset:]
+
+	switch {
+	case pid == 0:
+		ins.handleSection(pid, payload, unitStart)
+	case ins.pmtPIDs[pid]:
+		ins.handleSection(pid, payload, unitStart)
+	case int(pid) == ins.videoPID:
+		ins.handleVideo(payload, unitStart)
+	}
+}
+
+// handleSection 拼接PSI分段，完整后解析
+func (ins *inspector) handleSection(pid uint16, payload []byte, unitStart bool) {
+	if unitStart {
+		pointer := int(payload[0])
+		if 1+pointer >= len(payload) {
+			return
+		}
+		ins.sections[pid] = append([]byte(nil), payload[1+pointer:]...)
+	} else if buf, ok := ins.sections[pid]; ok && len(buf) > 0 {
+		ins.sections[pid] = append(buf, payload...)
+	} else {
+		return
+	}
+
+	section := ins.sections[pid]
+	if len(section) < 3 {
+		return
+	}
+	length := int(section[1]&0x0f)<<8 | int(section[2])
+	if len(section) < 3+length {
+		return // 等待后续包
+	}
+	section = section[:3+length]
+	delete(ins.sections, pid)
+
+	switch section[0] {
+	case 0x00:
+		ins.parsePAT(section)
+	case 0x02:
+		ins.parsePMT(section)
+	}
+}
+
+// parsePAT 解析节目关联表
+func (ins *inspector) parsePAT(section []byte) {
+	// 跳过8字节表头，末尾4字节CRC
+	for i := 8; i+4 <= len(section)-4; i += 4 {
+		program := uint16(section[i])<<8 | uint16(section[i+1])
+		pid := uint16(section[i+2]&0x1f)<<8 | uint16(section[i+3])
+		if program != 0 {
+			ins.pmtPIDs[pid] = true
+		}
+	}
+}
+
+// parsePMT 解析节目映射表，取第一路视频和音频
+func (ins *inspector) parsePMT(section []byte) {
+	if len(section) < 12 {
+		return
+	}
+	programInfoLength := int(section[10]&0x0f)<<8 | int(section[11])
+	end := len(section) - 4
+	for i := 12 + programInfoLength; i+5 <= end; {
+		streamType := section[i]
+		pid := int(section[i+1]&0x1f)<<8 | int(section[i+2])
+		esInfoLength := int(section[i+3]&0x0f)<<8 | int(section[i+4])
+		descriptorsEnd := i + 5 + esInfoLength
+		if descriptorsEnd > end {
+			descriptorsEnd = end
+		}
+		descriptors := section[i+5 : descriptorsEnd]
+		i = descriptorsEnd
+
+		codec, isVideo := streamCodec(streamType, descriptors)
+		if codec == "" {
+			continue
+		}
+		if isVideo && ins.videoPID < 0 {
+			ins.videoPID = pid
+			ins.info.VideoCodec = codec
+		} else if !isVideo && ins.audioPID < 0 {
+			ins.audioPID = pid
+			ins.info.AudioCodec = codec
+		}
+	}
+}
+
+// streamCodec 根据stream_type和描述符识别编码
+func streamCodec(streamType byte, descriptors []byte) (string, bool) {
+	switch streamType {
+	case 0x01:
+		return CodecMPEG1, true
+	case 0x02:
+		return CodecMPEG2, true
+	case 0x1b:
+		return CodecH264, true
+	case 0x24:
+		return CodecHEVC, true
+	case 0x03, 0x04:
+		return CodecMP2, false
+	case 0x0f, 0x11:
+		return CodecAAC, false
+	case 0x81:
+		return CodecAC3, false
+	case 0x87:
+		return CodecEAC3, false
+	case 0x06:
+		// DVB私有数据，通过描述符区分AC-3/E-AC-3
+		for i := 0; i+2 <= len(descriptors); {
+			tag, length := descriptors[i], int(descriptors[i+1])
+			if i+2+length > len(descriptors) {
+				break
+			}
+			body := descriptors[i+2 : i+2+length]
+			switch {
+			case tag == 0x6a:
+				return CodecAC3, false
+			case tag == 0x7a:
+				return CodecEAC3, false
+			case tag == 0x05 && bytes.Equal(body, []byte("AC-3")):
+				return CodecAC3, false
+			case tag == 0x05 && bytes.Equal(body, []byte("HEVC")):
+				return CodecHEVC, true
+			}
+			i += 2 + length
+		}
+	}
+	return "", false
+}
+
+// handleVideo 收集视频PES负载并查找序列参数
+func (ins *inspector) handleVideo(payload []byte, unitStart bool) {
+	if unitStart {
+		ins.parseVideo()
+		data, pts, ok := parsePESHeader(payload)
+		if !ok {
+			ins.video = nil
+			return
+		}
+		if pts >= 0 {
+			ins.pts = append(ins.pts, uint64(pts))
+		}
+		ins.video = append(ins.video[:0], data...)
+		return
+	}
+	if ins.video != nil && len(ins.video) < maxVideoBuffer {
+		ins.video = append(ins.video, payload...)
+	}
+}
+
+// parseVideo 在已收集的PES负载中查找SPS/序列头
+func (ins *inspector) parseVideo() {
+	if ins.gotSPS || len(ins.video) == 0 {
+		return
+	}
+
+	var params videoParams
+	var err error
+	found := false
+	forEachNAL(ins.video, func(nal []byte) bool {
+		switch ins.info.VideoCodec {
+		case CodecH264:
+			if nal[0]&0x1f == 7 {
+				params, err = parseH264SPS(nal)
+				found = true
+			}
+		case CodecHEVC:
+			if (nal[0]>>1)&0x3f == 33 {
+				params, err = parseHEVCSPS(nal)
+				found = true
+			}
+		case CodecMPEG1, CodecMPEG2:
+			if nal[0] == 0xb3 {
+				params, err = parseMPEG2SequenceHeader(nal[1:])
+				found = true
+			}
+		}
+		return !found
+	})
+
+	if !found || err != nil || params.width <= 0 || params.height <= 0 {
+		return
+	}
+	ins.gotSPS = true
+	ins.info.Width = params.width
+	ins.info.Height = params.height
+	if params.frameRate > 0 {
+		ins.info.FrameRate = params.frameRate
+	}
+}
+
+// finish 处理最后一个PES，并在码流未声明帧率时根据PTS估算
+func (ins *inspector) finish() {
+	ins.parseVideo()
+	if ins.info.FrameRate == 0 {
+		ins.info.FrameRate = frameRateFromPTS(ins.pts)
+	}
+}
+
+// parsePESHeader 解析PES头，返回负载和PTS（无PTS时为-1）
+func parsePESHeader(data []byte) ([]byte, int64, bool) {
+	if len(data) < 9 || data[0] != 0 || data[1] != 0 || data[2] != 1 {
+		return nil, -1, false
+	}
+	headerLength := int(data[8])
+	if 9+headerLength > len(data) {
+		return nil, -1, false
+	}
+
+	pts := int64(-1)
+	if data[7]&0x80 != 0 && headerLength >= 5 {
+		b := data[9:14]
+		pts = int64(b[0]>>1&0x07)<<30 | int64(b[1])<<22 | int64(b[2]>>1)<<15 | int64(b[3])<<7 | int64(b[4]>>1)
+	}
+	return data[9+headerLength:], pts, true
+}
+
+// forEachNAL 按起始码（00 00 01）切分数据，fn返回false时停止
+func forEachNAL(data []byte, fn func(nal []byte) bool) {
+	start := -1
+	for i := 0; i+3 <= len(data); i++ {
+		if data[i] != 0 || data[i+1] != 0 || data[i+2] != 1 {
+			continue
+		}
+		if start >= 0 {
+			if nal := bytes.TrimRight(data[start:i], "\x00"); len(nal) > 0 && !fn(nal) {
+				return
+			}
+		}
+		start = i + 3
+		i += 2
+	}
+	if start >= 0 && start < len(data) {
+		fn(data[start:])
+	}
+}
+
+// frameRateFromPTS 根据PTS间隔（90kHz）估算帧率，先排序以消除B帧重排的影响
+func frameRateFromPTS(pts []uint64) float64 {
+	sorted := append([]uint64(nil), pts...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	var deltas []uint64
+	for i := 1; i < len(sorted); i++ {
+		if sorted[i] > sorted[i-1] {
+			deltas = append(deltas, sorted[i]-sorted[i-1])
+		}
+	}
+	if len(deltas) < 3 {
+		return 0
+	}
+	sort.Slice(deltas, func(i, j int) bool { return deltas[i] < deltas[j] })
+	median := deltas[len(deltas)/2]
+	if median == 0 {
+		return 0
+	}
+	return 90000 / float64(median)
+}
@@ -0,0 +1,164 @@
+package mpegts
+
+import (
+	"testing"
+)
+
+// bitWriter 构造测试用的SPS
+type bitWriter struct {
+	data []byte
+	bits int
+}
+
+func (w *bitWriter) u(n int, v uint32) {
+	for i := n - 1; i >= 0; i-- {
+		if w.bits%8 == 0 {
+			w.data = append(w.data, 0)
+		}
+		if (v>>uint(i))&1 == 1 {
+			w.data[len(w.data)-1] |= 1 << (7 - uint(w.bits%8))
+		}
+		w.bits++
+	}
+}
+
+func (w *bitWriter) ue(v uint32) {
+	v++
+	n := 0
+	for t := v; t > 1; t >>= 1 {
+		n++
+	}
+	w.u(n, 0)
+	w.u(n+1, v)
+}
+
+// buildH264SPS 1920x1080 High Profile，VUI声明25fps
+func buildH264SPS() []byte {
+	w := &bitWriter{}
+	w.u(8, 0x67) // NAL头
+	w.u(8, 100)  // profile_idc
+	w.u(8, 0)    // constraint flags
+	w.u(8, 40)   // level_idc
+	w.ue(0)      // seq_parameter_set_id
+	w.ue(1)      // chroma_format_idc
+	w.ue(0)      // bit_depth_luma_minus8
+	w.ue(0)      // bit_depth_chroma_minus8
+	w.u(1, 0)    // qpprime_y_zero_transform_bypass_flag
+	w.u(1, 0)    // seq_scaling_matrix_present_flag
+	w.ue(0)      // log2_max_frame_num_minus4
+	w.ue(0)      // pic_order_cnt_type
+	w.ue(2)      // log2_max_pic_order_cnt_lsb_minus4
+	w.ue(4)      // max_num_ref_frames
+	w.u(1, 0)    // gaps_in_frame_num_value_allowed_flag
+	w.ue(119)    // pic_width_in_mbs_minus1
+	w.ue(67)     // pic_height_in_map_units_minus1
+	w.u(1, 1)    // frame_mbs_only_flag
+	w.u(1, 1)    // direct_8x8_inference_flag
+	w.u(1, 1)    // frame_cropping_flag
+	w.ue(0)
+	w.ue(0)
+	w.ue(0)
+	w.ue(4)   // 底部裁剪8行
+	w.u(1, 1) // vui_parameters_present_flag
+	w.u(1, 0) // aspect_ratio_info_present_flag
+	w.u(1, 0) // overscan_info_present_flag
+	w.u(1, 0) // video_signal_type_present_flag
+	w.u(1, 0) // chroma_loc_info_present_flag
+	w.u(1, 1) // timing_info_present_flag
+	w.u(32, 1)
+	w.u(32, 50)
+	w.u(1, 1)
+	w.u(1, 1) // rbsp_stop_one_bit
+	return w.data
+}
+
+// packetize 将负载封装为TS包
+func packetize(pid uint16, payload []byte, unitStart bool) [][]byte {
+	var packets [][]byte
+	for first := true; len(payload) > 0 || first; first = false {
+		packet := make([]byte, PacketSize)
+		packet[0] = syncByte
+		packet[1] = byte(pid >> 8 & 0x1f)
+		if first && unitStart {
+			packet[1] |= 0x40
+		}
+		packet[2] = byte(pid)
+		packet[3] = 0x10
+		n := copy(packet[4:], payload)
+		for i := 4 + n; i < PacketSize; i++ {
+			packet[i] = 0xff
+		}
+		payload = payload[n:]
+		packets = append(packets, packet)
+	}
+	return packets
+}
+
+// section 构造PSI分段（CRC不校验，填0）
+func section(tableID byte, body []byte) []byte {
+	length := len(body) + 5 + 4
+	data := []byte{0x00, tableID, 0xb0 | byte(length>>8), byte(length), 0x00, 0x01, 0xc1, 0x00, 0x00}
+	data = append(data, body...)
+	return append(data, 0, 0, 0, 0)
+}
+
+func TestInspectH264(t *testing.T) {
+	pat := section(0x00, []byte{0x00, 0x01, 0xe1, 0x00}) // program 1 -> PMT PID 0x100
+	pmt := section(0x02, []byte{
+		0xe1, 0x01, 0xf0, 0x00, // PCR PID + program_info_length
+		0x1b, 0xe1, 0x01, 0xf0, 0x00, // H.264 PID 0x101
+		0x0f, 0xe1, 0x02, 0xf0, 0x00, // AAC PID 0x102
+	})
+
+	var stream []byte
+	for _, p := range packetize(0, pat, true) {
+		stream = append(stream, p...)
+	}
+	for _, p := range packetize(0x100, pmt, true) {
+		stream = append(stream, p...)
+	}
+
+	sps := buildH264SPS()
+	for frame := 0; frame < 4; frame++ {
+		pts := uint64(frame * 3600)
+		pes := []byte{0, 0, 1, 0xe0, 0, 0, 0x80, 0x80, 5,
+			byte(0x21 | (pts>>29)&0x0e), byte(pts >> 22), byte((pts>>14)&0xfe | 1), byte(pts >> 7), byte(pts<<1 | 1)}
+		pes = append(pes, 0, 0, 0, 1)
+		pes = append(pes, sps...)
+		pes = append(pes, 0, 0, 0, 1, 0x65, 0x88, 0x84)
+		for _, p := range packetize(0x101, pes, true) {
+			stream = append(stream, p...)
+		}
+	}
+
+	// 开头加入垃圾数据，验证重新同步
+	stream = append([]byte{0x01, 0x02, 0x03}, stream...)
+
+	info, err := InspectBytes(stream)
+	if err != nil {
+		t.Fatalf("InspectBytes: %v", err)
+	}
+	if info.VideoCodec != CodecH264 || info.AudioCodec != CodecAAC {
+		t.Errorf("codec = %s/%s, want h264/aac", info.VideoCodec, info.AudioCodec)
+	}
+	if info.Width != 1920 || info.Height != 1080 {
+		t.Errorf("resolution = %dx%d, want 1920x1080", info.Width, info.Height)
+	}
+	if info.FrameRate != 25 {
+		t.Errorf("frame rate = %v, want 25", info.FrameRate)
+	}
+}
+
+func TestFrameRateFromPTS(t *testing.T) {
+	// B帧导致PTS乱序
+	pts := []uint64{0, 5400, 1800, 3600, 10800, 7200, 9000}
+	if got := frameRateFromPTS(pts); got != 50 {
+		t.Errorf("frameRateFromPTS = %v, want 50", got)
+	}
+}
+
+func TestInspectNotTS(t *testing.T) {
+	if _, err := InspectBytes([]byte("<html>not a stream</html>")); err == nil {
+		t.Error("expected error for non-TS data")
+	}
+}
@@ -0,0 +1,208 @@
+package normalize
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"gopkg.in/yaml.v3"
+)
+
+// builtinAliases 内置别名表：标准名称 -> 别名
+var builtinAliases = map[string][]string{
+	"CCTV1":   {"中央一台", "央视一套", "CCTV综合"},
+	"CCTV2":   {"中央二台", "央视二套", "CCTV财经"},
+	"CCTV3":   {"中央三台", "央视三套", "CCTV综艺"},
+	"CCTV4":   {"中央四台", "央视四套", "CCTV中文国际"},
+	"CCTV5":   {"中央五台", "央视五套", "CCTV体育"},
+	"CCTV5+":  {"CCTV5PLUS", "CCTV5+体育赛事", "CCTV体育赛事", "央视五套+"},
+	"CCTV6":   {"中央六台", "央视六套", "CCTV电影"},
+	"CCTV7":   {"中央七台", "央视七套", "CCTV国防军事", "CCTV军事农业"},
+	"CCTV8":   {"中央八台", "央视八套", "CCTV电视剧"},
+	"CCTV9":   {"中央九台", "央视九套", "CCTV纪录", "CCTV记录"},
+	"CCTV10":  {"中央十台", "CCTV科教"},
+	"CCTV11":  {"CCTV戏曲"},
+	"CCTV12":  {"CCTV社会与法"},
+	"CCTV13":  {"CCTV新闻", "央视新闻"},
+	"CCTV14":  {"CCTV少儿"},
+	"CCTV15":  {"CCTV音乐"},
+	"CCTV16":  {"CCTV奥林匹克"},
+	"CCTV17":  {"CCTV农业农村"},
+	"CCTV4K":  {"CCTV4K超高清", "CCTV超高清"},
+	"CGTN":    {"CGTN英语", "CGTN新闻", "CCTVNEWS"},
+	"CETV1":   {"中国教育1台", "中国教育一台", "中国教育电视台1", "CETV-1"},
+	"CETV2":   {"中国教育2台", "中国教育二台", "中国教育电视台2"},
+	"北京卫视":    {"BTV卫视", "BTV北京卫视"},
+	"东方卫视":    {"上海东方卫视", "SiTV东方卫视", "东方卫视STV"},
+	"深圳卫视":    {"SZTV卫视"},
+	"金鹰卡通":    {"湖南金鹰卡通"},
+	"凤凰卫视中文台": {"凤凰中文", "凤凰卫视中文"},
+	"凤凰卫视资讯台": {"凤凰资讯", "凤凰卫视资讯"},
+}
+
+// CCTV频道序号后的描述性文字，去掉后只保留序号
+var cctvDescriptions = []string{
+	"综合", "财经", "综艺", "中文国际", "体育赛事", "体育", "电影", "国防军事", "军事",
+	"电视剧", "纪录", "记录", "科教", "戏曲", "社会与法", "新闻", "少儿", "音乐", "农业农村", "奥林匹克",
+}
+
+// 名称末尾的清晰度/画质标记
+var qualitySuffixes = []string{
+	"超高清", "超清", "高清", "标清", "蓝光", "FHD", "UHD", "HD", "SD",
+	"1080P", "1080I", "720P", "576I", "50FPS", "60FPS", "HEVC", "H265", "H264",
+}
+
+var (
+	cctvPattern    = regexp.MustCompile(`^CCTV(\d{1,2})(\+|PLUS)?(.*)$`)
+	bracketPattern = regexp.MustCompile(`[\(\[（【][^\)\]）】]*[\)\]）】]`)
+	spacePattern   = regexp.MustCompile(`\s+`)
+)
+
+// Normalizer 频道名称规范化器
+type Normalizer struct {
+	aliases map[string]string // 别名key -> 标准名称
+}
+
+// New 创建规范化器，aliasFile为用户别名文件（可为空，文件不存在时仅使用内置别名表）
+func New(aliasFile string) (*Normalizer, error) {
+	n := &Normalizer{aliases: make(map[string]string)}
+	n.addAliases(builtinAliases)
+
+	if aliasFile == "" {
+		return n, nil
+	}
+
+	data, err := os.ReadFile(aliasFile)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return n, nil
+		}
+		return n, fmt.Errorf("读取别名文件失败: %v", err)
+	}
+
+	var userAliases map[string][]string
+	err = yaml.Unmarshal(data, &userAliases)
+	if err != nil {
+		return n, fmt.Errorf("解析别名文件失败: %v", err)
+	}
+
+	// 用户别名覆盖内置别名
+	n.addAliases(userAliases)
+	return n, nil
+}
+
+// addAliases 添加别名表，标准名称本身也作为别名
+func (n *Normalizer) addAliases(aliases map[string][]string) {
+	for name, list := range aliases {
+		n.aliases[key(name)] = name
+		for _, alias := range list {
+			n.aliases[key(alias)] = name
+		}
+	}
+}
+
+// Name 返回频道的标准名称
+func (n *Normalizer) Name(raw string) string {
+	display := clean(raw)
+	if display == "" {
+		return strings.TrimSpace(raw)
+	}
+
+	k := key(display)
+	if name, ok := n.aliases[k]; ok {
+		return name
+	}
+
+	// CCTV-1综合 / CCTV 1 / cctv1高清 -> CCTV1
+	if m := cctvPattern.FindStringSubmatch(k); m != nil {
+		name := "CCTV" + strings.TrimLeft(m[1], "0")
+		if m[2] != "" {
+			name += "+"
+		}
+		rest := m[3]
+		for _, desc := range cctvDescriptions {
+			rest = strings.TrimPrefix(rest, desc)
+		}
+		if rest != "" {
+			name += rest
+		}
+		if alias, ok := n.aliases[key(name)]; ok {
+			return alias
+		}
+		return name
+	}
+
+	return display
+}
+
+// clean 统一全角字符、去掉括号备注和清晰度后缀，中文名称去掉空格
+func clean(s string) string {
+	s = foldWidth(strings.TrimSpace(s))
+	s = bracketPattern.ReplaceAllString(s, " ")
+	s = strings.TrimSpace(spacePattern.ReplaceAllString(s, " "))
+
+	for {
+		trimmed := trimQualitySuffix(s)
+		if trimmed == s {
+			break
+		}
+		s = trimmed
+	}
+
+	if hasHan(s) {
+		s = strings.ReplaceAll(s, " ", "")
+	}
+	return s
+}
+
+// trimQualitySuffix 去掉一个末尾清晰度标记（不区分大小写，连同前面的分隔符）
+func trimQualitySuffix(s string) string {
+	for _, suffix := range qualitySuffixes {
+		if len(s) <= len(suffix) {
+			continue
+		}
+		if strings.EqualFold(s[len(s)-len(suffix):], suffix) {
+			return strings.TrimRight(s[:len(s)-len(suffix)], " -_")
+		}
+	}
+	return s
+}
+
+// key 生成用于匹配的key：大写并去掉空格和分隔符
+func key(s string) string {
+	s = strings.ToUpper(foldWidth(s))
+	return strings.Map(func(r rune) rune {
+		switch {
+		case unicode.IsSpace(r):
+			return -1
+		case strings.ContainsRune("-_·.|:：", r):
+			return -1
+		}
+		return r
+	}, s)
+}
+
+// foldWidth 全角字符转半角
+func foldWidth(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r == '　':
+			return ' '
+		case r >= '！' && r <= '～':
+			return r - 0xfee0
+		}
+		return r
+	}, s)
+}
+
+// hasHan 是否包含汉字
+func hasHan(s string) bool {
+	for _, r := range s {
+		if unicode.Is(unicode.Han, r) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,33 @@
+package normalize
+
+import "testing"
+
+func TestName(t *testing.T) {
+	n, err := New("")
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	cases := map[string]string{
+		"CCTV-1综合":    "CCTV1",
+		"CCTV1 HD":    "CCTV1",
+		"cctv1高清":     "CCTV1",
+		"CCTV 1":      "CCTV1",
+		"ＣＣＴＶ－１":      "CCTV1",
+		"CCTV-5+体育赛事": "CCTV5+",
+		"CCTV5PLUS":   "CCTV5+",
+		"CCTV-13 新闻":  "CCTV13",
+		"CCTV4K超高清":   "CCTV4K",
+		"CCTV-4 欧洲":   "CCTV4欧洲",
+		"湖南卫视 HD":     "湖南卫视",
+		"湖南卫视(1080P)": "湖南卫视",
+		"北京卫视【高清】":    "北京卫视",
+		"BTV卫视":       "北京卫视",
+		"HBO HD":      "HBO",
+	}
+	for raw, want := range cases {
+		if got := n.Name(raw); got != want {
+			t.Errorf("Name(%q) = %q, want %q", raw, got, want)
+		}
+	}
+}
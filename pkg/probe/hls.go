@@ -0,0 +1,175 @@
+package probe
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"iptv/dto"
+	"iptv/pkg/hls"
+	"iptv/pkg/mpegts"
+)
+
+const (
+	maxPlaylistSize = 1 << 20  // 播放列表最大读取1MB
+	maxSegmentSize  = 32 << 20 // 分片最大读取32MB
+	maxPlaylistHops = 3        // 主播放列表最多跟随的层数
+)
+
+// isHLS 根据地址、Content-Type和首个数据块判断是否为HLS播放列表
+func isHLS(rawURL string, contentType string, head []byte) bool {
+	if hls.IsPlaylist(head) {
+		return true
+	}
+	contentType = strings.ToLower(contentType)
+	if strings.Contains(contentType, "mpegurl") {
+		return true
+	}
+	if parsed, err := url.Parse(rawURL); err == nil {
+		return strings.EqualFold(path.Ext(parsed.Path), ".m3u8")
+	}
+	return false
+}
+
+// checkHLS 解析播放列表，跟随码流并下载分片，确认流真正可以播放
+func checkHLS(ctx context.Context, playlistURL string, body []byte, latency time.Duration, opts Options) dto.StreamInfo {
+	info := dto.StreamInfo{Latency: latency}
+
+	for hop := 0; ; hop++ {
+		playlist, err := hls.Parse(body)
+		if err != nil {
+			return dead(info, err.Error())
+		}
+
+		if !playlist.Master {
+			return checkSegments(ctx, playlistURL, playlist, info, opts)
+		}
+
+		if hop >= maxPlaylistHops {
+			return dead(info, "播放列表嵌套层数过多")
+		}
+
+		// 按声明带宽从高到低尝试每一路码流，使用第一路可用的
+		variants := append([]hls.Variant(nil), playlist.Variants...)
+		sort.SliceStable(variants, func(i, j int) bool {
+			return variants[i].Bandwidth > variants[j].Bandwidth
+		})
+
+		var lastErr error
+		body = nil
+		for _, variant := range variants {
+			variantURL := hls.ResolveURI(playlistURL, variant.URI)
+			data, _, err := fetch(ctx, variantURL, maxPlaylistSize)
+			if err != nil {
+				lastErr = err
+				if ctx.Err() != nil {
+					break
+				}
+				continue
+			}
+
+			info.Bandwidth = variant.Bandwidth
+			info.Resolution = variant.Resolution
+			info.Codecs = variant.Codecs
+			playlistURL, body = variantURL, data
+			break
+		}
+
+		if body == nil {
+			if lastErr == nil {
+				lastErr = fmt.Errorf("没有可用的码流")
+			}
+			return failure(ctx, fmt.Errorf("码流不可用: %v", lastErr))
+		}
+	}
+}
+
+// checkSegments 下载媒体播放列表中最新的几个分片并计算实际下载速率
+func checkSegments(ctx context.Context, playlistURL string, playlist *hls.Playlist, info dto.StreamInfo, opts Options) dto.StreamInfo {
+	if len(playlist.Segments) == 0 {
+		return dead(info, "播放列表没有分片")
+	}
+
+	count := opts.Segments
+	if count <= 0 {
+		count = 1
+	}
+	if count > len(playlist.Segments) {
+		count = len(playlist.Segments)
+	}
+
+	// 直播列表中靠前的分片可能已过期，取最新的分片
+	segments := playlist.Segments[len(playlist.Segments)-count:]
+	if playlist.Ended {
+		segments = playlist.Segments[:count]
+	}
+
+	var totalBytes int
+	var totalTime time.Duration
+	for i, segment := range segments {
+		data, elapsed, err := fetch(ctx, hls.ResolveURI(playlistURL, segment.URI), maxSegmentSize)
+		if err != nil {
+			return failure(ctx, fmt.Errorf("分片不可用: %v", err))
+		}
+		if len(data) == 0 {
+			return dead(info, "分片为空")
+		}
+		if i == 0 && opts.Inspect {
+			// fMP4分片无法解析，忽略错误
+			if ts, err := mpegts.InspectBytes(data); err == nil {
+				applyTSInfo(&info, ts)
+			}
+		}
+		totalBytes += len(data)
+		totalTime += elapsed
+	}
+
+	if totalTime > 0 {
+		info.Mbps = float64(totalBytes*8) / totalTime.Seconds() / 1e6
+	}
+	info.Status = dto.StatusAlive
+	return info
+}
+
+// fetch 下载完整响应体，返回数据和耗时
+func fetch(ctx context.Context, rawURL string, limit int64) ([]byte, time.Duration, error) {
+	start := time.Now()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, 0, err
+	}
+	req.Header.Set("User-Agent", userAgent)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, 0, fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+
+	var buf bytes.Buffer
+	_, err = io.Copy(&buf, io.LimitReader(resp.Body, limit))
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return buf.Bytes(), time.Since(start), nil
+}
+
+// dead 标记为失效并保留已收集的信息
+func dead(info dto.StreamInfo, reason string) dto.StreamInfo {
+	info.Status = dto.StatusDead
+	info.Reason = reason
+	return info
+}
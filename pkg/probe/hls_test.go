@@ -0,0 +1,124 @@
+package probe
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"iptv/dto"
+)
+
+const masterPlaylist = `#EXTM3U
+#EXT-X-STREAM-INF:BANDWIDTH=800000,RESOLUTION=640x360,CODECS="avc1.4d401e,mp4a.40.2"
+low/index.m3u8
+#EXT-X-STREAM-INF:BANDWIDTH=4000000,RESOLUTION=1920x1080,CODECS="avc1.640028,mp4a.40.2"
+high/index.m3u8
+`
+
+const mediaPlaylist = `#EXTM3U
+#EXT-X-TARGETDURATION:4
+#EXT-X-MEDIA-SEQUENCE:100
+#EXTINF:4.0,
+old.ts
+#EXTINF:4.0,
+seg1.ts
+#EXTINF:4.0,
+seg2.ts
+`
+
+func TestCheckHLSFollowsMasterPlaylist(t *testing.T) {
+	var mu sync.Mutex
+	var fetched []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		fetched = append(fetched, r.URL.Path)
+		mu.Unlock()
+		switch r.URL.Path {
+		case "/live/master.m3u8":
+			w.Write([]byte(masterPlaylist))
+		case "/live/high/index.m3u8":
+			// 最高码流失效时使用下一路
+			http.NotFound(w, r)
+		case "/live/low/index.m3u8":
+			w.Write([]byte(mediaPlaylist))
+		case "/live/low/seg1.ts", "/live/low/seg2.ts":
+			time.Sleep(10 * time.Millisecond)
+			w.Write(make([]byte, 188*100))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	info := Check(srv.URL+"/live/master.m3u8", Options{Timeout: 2 * time.Second, Segments: 2})
+	if info.Status != dto.StatusAlive {
+		t.Fatalf("Status = %q (%s)", info.Status, info.Reason)
+	}
+	if info.Bandwidth != 800000 || info.Resolution != "640x360" || info.Codecs != "avc1.4d401e,mp4a.40.2" {
+		t.Errorf("variant = %d %q %q", info.Bandwidth, info.Resolution, info.Codecs)
+	}
+
+	want := "/live/master.m3u8 /live/high/index.m3u8 /live/low/index.m3u8 /live/low/seg1.ts /live/low/seg2.ts"
+	if got := strings.Join(fetched, " "); got != want {
+		t.Errorf("fetched = %s, want %s", got, want)
+	}
+
+	// 两个分片共 37600 字节，每个至少 10ms，速率不超过 37600*8/0.02/1e6 ≈ 15 Mbps
+	if info.Mbps <= 0 || info.Mbps > 15.04 {
+		t.Errorf("Mbps = %v", info.Mbps)
+	}
+}
+
+func TestCheckHLSDeadSegment(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/index.m3u8" {
+			w.Write([]byte(mediaPlaylist))
+			return
+		}
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer srv.Close()
+
+	info := Check(srv.URL+"/index.m3u8", Options{Timeout: time.Second})
+	if info.Status != dto.StatusDead || info.Reason != "分片不可用: HTTP 403" {
+		t.Errorf("Status = %q, Reason = %q", info.Status, info.Reason)
+	}
+
+	// 只检查首个数据块时不跟随播放列表
+	if info := Quick(srv.URL+"/index.m3u8", time.Second); info.Status != dto.StatusAlive {
+		t.Errorf("Quick Status = %q (%s)", info.Status, info.Reason)
+	}
+}
+
+func TestCheckSegmentsMbps(t *testing.T) {
+	var mu sync.Mutex
+	var fetched []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		fetched = append(fetched, r.URL.Path)
+		mu.Unlock()
+		if r.URL.Path == "/vod.m3u8" {
+			w.Write([]byte("#EXTM3U\n#EXTINF:4.0,\nfirst.ts\n#EXTINF:4.0,\nlast.ts\n#EXT-X-ENDLIST\n"))
+			return
+		}
+		time.Sleep(50 * time.Millisecond)
+		w.Write(make([]byte, 125000))
+	}))
+	defer srv.Close()
+
+	info := Check(srv.URL+"/vod.m3u8", Options{Timeout: 2 * time.Second})
+	if info.Status != dto.StatusAlive {
+		t.Fatalf("Status = %q (%s)", info.Status, info.Reason)
+	}
+	// 点播列表从第一个分片开始下载
+	if got := strings.Join(fetched, " "); got != "/vod.m3u8 /first.ts" {
+		t.Errorf("fetched = %s", got)
+	}
+	// 125000 字节至少用时 50ms，速率不超过 20 Mbps
+	if info.Mbps <= 0 || info.Mbps > 20 {
+		t.Errorf("Mbps = %v", info.Mbps)
+	}
+}
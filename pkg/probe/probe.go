@@ -0,0 +1,206 @@
+package probe
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"iptv/dto"
+	"iptv/pkg/mpegts"
+)
+
+// 探测请求使用的User-Agent（模拟常见播放器）
+const userAgent = "VLC/3.0.20 LibVLC/3.0.20"
+
+// 读取多少字节即认为流可播放
+const firstChunkSize = 1024
+
+// 解析TS流时最多读取的包数
+const inspectPackets = 4000
+
+// Options 探测参数
+type Options struct {
+	Timeout    time.Duration // 单个URL的探测超时
+	MaxWorkers int           // 最大并发数
+	Segments   int           // HLS流下载的分片数
+	Inspect    bool          // 是否解析TS流的编码和分辨率
+	Shallow    bool          // 只检查首个数据块，不跟随HLS播放列表
+}
+
+var client = &http.Client{
+	Transport: &http.Transport{
+		Proxy:               http.ProxyFromEnvironment,
+		MaxIdleConnsPerHost: 4,
+		IdleConnTimeout:     30 * time.Second,
+	},
+}
+
+// Run 并发探测所有频道，结果写入每个频道的Stream字段
+func Run(channels []dto.Channel, opts Options) []dto.Channel {
+	if opts.Timeout <= 0 {
+		opts.Timeout = 5 * time.Second
+	}
+	if opts.MaxWorkers <= 0 {
+		opts.MaxWorkers = 10
+	}
+
+	// 同一个URL只探测一次
+	var urls []string
+	seen := make(map[string]bool)
+	for _, ch := range channels {
+		if !seen[ch.URL] {
+			seen[ch.URL] = true
+			urls = append(urls, ch.URL)
+		}
+	}
+
+	results := make(map[string]dto.StreamInfo, len(urls))
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	workerChan := make(chan struct{}, opts.MaxWorkers)
+
+	for _, u := range urls {
+		workerChan <- struct{}{}
+		wg.Add(1)
+		go func(u string) {
+			defer func() {
+				<-workerChan
+				wg.Done()
+			}()
+
+			info := Check(u, opts)
+			mu.Lock()
+			results[u] = info
+			mu.Unlock()
+		}(u)
+	}
+	wg.Wait()
+
+	probed := make([]dto.Channel, len(channels))
+	for i, ch := range channels {
+		ch.Stream = results[ch.URL]
+		probed[i] = ch
+	}
+	return probed
+}
+
+// Check 探测单个URL
+func Check(rawURL string, opts Options) dto.StreamInfo {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return dto.StreamInfo{Status: dto.StatusDead, Reason: fmt.Sprintf("解析URL失败: %v", err)}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), opts.Timeout)
+	defer cancel()
+
+	switch strings.ToLower(parsed.Scheme) {
+	case "http", "https":
+		return checkHTTP(ctx, rawURL, opts)
+	case "rtsp":
+		return checkTCP(ctx, parsed, "554")
+	case "rtmp":
+		return checkTCP(ctx, parsed, "1935")
+	default:
+		// udp/rtp组播只能在局域网内接收，无法远程探测
+		return dto.StreamInfo{Status: dto.StatusSkipped, Reason: "不支持探测的协议: " + parsed.Scheme}
+	}
+}
+
+// Quick 快速检查URL是否可以连接并返回数据（用于播放前的实时检查）
+func Quick(rawURL string, timeout time.Duration) dto.StreamInfo {
+	return Check(rawURL, Options{Timeout: timeout, Shallow: true})
+}
+
+// checkHTTP 请求流地址并读取首个数据块，HLS播放列表会继续检查分片
+func checkHTTP(ctx context.Context, rawURL string, opts Options) dto.StreamInfo {
+	start := time.Now()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return dto.StreamInfo{Status: dto.StatusDead, Reason: fmt.Sprintf("创建请求失败: %v", err)}
+	}
+	req.Header.Set("User-Agent", userAgent)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return failure(ctx, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return dto.StreamInfo{Status: dto.StatusDead, Reason: fmt.Sprintf("HTTP %d", resp.StatusCode)}
+	}
+
+	buf := make([]byte, firstChunkSize)
+	n, err := io.ReadAtLeast(resp.Body, buf, 1)
+	if n == 0 {
+		if err == nil || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			return dto.StreamInfo{Status: dto.StatusDead, Reason: "响应为空"}
+		}
+		return failure(ctx, err)
+	}
+	latency := time.Since(start)
+
+	if !opts.Shallow && isHLS(rawURL, resp.Header.Get("Content-Type"), buf[:n]) {
+		rest, err := io.ReadAll(io.LimitReader(resp.Body, maxPlaylistSize-int64(n)))
+		if err != nil {
+			return failure(ctx, err)
+		}
+		body := append(buf[:n], rest...)
+		return checkHLS(ctx, resp.Request.URL.String(), body, latency, opts)
+	}
+
+	info := dto.StreamInfo{Status: dto.StatusAlive, Latency: latency}
+	if opts.Inspect && !opts.Shallow {
+		// 继续读取原始TS流，超时只影响检测结果，不影响可用性
+		ts, err := mpegts.Inspect(io.MultiReader(bytes.NewReader(buf[:n]), resp.Body), inspectPackets)
+		if err == nil {
+			applyTSInfo(&info, ts)
+		}
+	}
+	return info
+}
+
+// applyTSInfo 将TS检测结果写入探测信息
+func applyTSInfo(info *dto.StreamInfo, ts *mpegts.Info) {
+	info.VideoCodec = ts.VideoCodec
+	info.AudioCodec = ts.AudioCodec
+	info.Width = ts.Width
+	info.Height = ts.Height
+	info.FrameRate = ts.FrameRate
+}
+
+// checkTCP 检查rtsp/rtmp服务端口是否可连接
+func checkTCP(ctx context.Context, parsed *url.URL, defaultPort string) dto.StreamInfo {
+	host := parsed.Host
+	if parsed.Port() == "" {
+		host = net.JoinHostPort(parsed.Hostname(), defaultPort)
+	}
+
+	start := time.Now()
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", host)
+	if err != nil {
+		return failure(ctx, err)
+	}
+	conn.Close()
+
+	return dto.StreamInfo{Status: dto.StatusAlive, Latency: time.Since(start)}
+}
+
+// failure 根据错误类型生成探测结果
+func failure(ctx context.Context, err error) dto.StreamInfo {
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return dto.StreamInfo{Status: dto.StatusTimeout, Reason: "探测超时"}
+	}
+	return dto.StreamInfo{Status: dto.StatusDead, Reason: err.Error()}
+}
@@ -0,0 +1,89 @@
+package probe
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"iptv/dto"
+)
+
+func TestCheckHTTP(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/live.ts":
+			w.Write(make([]byte, 188))
+		case "/empty.ts":
+		case "/missing.ts":
+			http.NotFound(w, r)
+		case "/error.ts":
+			w.WriteHeader(http.StatusBadGateway)
+		case "/slow.ts":
+			select {
+			case <-r.Context().Done():
+			case <-time.After(5 * time.Second):
+			}
+		}
+	}))
+	defer srv.Close()
+
+	tests := []struct {
+		path   string
+		status string
+		reason string
+	}{
+		{"/live.ts", dto.StatusAlive, ""},
+		{"/empty.ts", dto.StatusDead, "响应为空"},
+		{"/missing.ts", dto.StatusDead, "HTTP 404"},
+		{"/error.ts", dto.StatusDead, "HTTP 502"},
+		{"/slow.ts", dto.StatusTimeout, "探测超时"},
+	}
+	for _, tt := range tests {
+		info := Check(srv.URL+tt.path, Options{Timeout: 200 * time.Millisecond})
+		if info.Status != tt.status || info.Reason != tt.reason {
+			t.Errorf("%s: Status = %q, Reason = %q, want %q, %q", tt.path, info.Status, info.Reason, tt.status, tt.reason)
+		}
+		if tt.status == dto.StatusAlive && info.Latency <= 0 {
+			t.Errorf("%s: Latency = %v", tt.path, info.Latency)
+		}
+	}
+}
+
+func TestCheckUnsupportedScheme(t *testing.T) {
+	info := Check("rtp://239.1.1.1:5000", Options{Timeout: time.Second})
+	if info.Status != dto.StatusSkipped {
+		t.Errorf("Status = %q, want %q", info.Status, dto.StatusSkipped)
+	}
+}
+
+func TestRunProbesEachURLOnce(t *testing.T) {
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		if r.URL.Path == "/dead.ts" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write(make([]byte, 188))
+	}))
+	defer srv.Close()
+
+	channels := []dto.Channel{
+		{Name: "CCTV1", URL: srv.URL + "/live.ts"},
+		{Name: "CCTV-1", URL: srv.URL + "/live.ts"},
+		{Name: "CCTV2", URL: srv.URL + "/dead.ts"},
+	}
+	probed := Run(channels, Options{Timeout: time.Second, MaxWorkers: 1})
+
+	if n := requests.Load(); n != 2 {
+		t.Errorf("requests = %d, want 2", n)
+	}
+	want := []string{dto.StatusAlive, dto.StatusAlive, dto.StatusDead}
+	for i, ch := range probed {
+		if ch.Name != channels[i].Name || ch.Stream.Status != want[i] {
+			t.Errorf("probed[%d] = %s %q, want %s %q", i, ch.Name, ch.Stream.Status, channels[i].Name, want[i])
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package playlist
+
+import (
+	"fmt"
+
+	"iptv/dto"
+	"iptv/pkg/config"
+	httppkg "iptv/pkg/http"
+	"iptv/pkg/provider"
+)
+
+func init() {
+	provider.Register("m3u", factory(dto.ParseM3U))
+	provider.Register("txt", factory(dto.ParseTXT))
+}
+
+// Provider 导入本地或远程的播放列表（M3U/M3U8 或 DIYP/TXT）
+type Provider struct {
+	name        string
+	client      *httppkg.Client
+	sources     []string
+	priority    int
+	groupPrefix string
+	parse       func(content string) []dto.Channel
+}
+
+// factory 返回使用指定解析函数的工厂
+func factory(parse func(content string) []dto.Channel) provider.Factory {
+	return func(cfg config.ProviderConfig) (provider.Provider, error) {
+		if len(cfg.Sources) == 0 {
+			return nil, fmt.Errorf("来源%s没有配置sources", cfg.Name)
+		}
+		client, err := httppkg.NewClient(httppkg.Options{Profile: cfg.Profile, Proxies: cfg.Proxies})
+		if err != nil {
+			return nil, fmt.Errorf("来源%s: %v", cfg.Name, err)
+		}
+		return &Provider{
+			name:        cfg.Name,
+			client:      client,
+			sources:     cfg.Sources,
+			priority:    cfg.Priority,
+			groupPrefix: cfg.GroupPrefix,
+			parse:       parse,
+		}, nil
+	}
+}
+
+// Name 来源名称
+func (p *Provider) Name() string {
+	return p.name
+}
+
+// Discover 返回配置的文件路径和地址
+func (p *Provider) Discover() ([]string, error) {
+	return p.sources, nil
+}
+
+// Fetch 读取并解析播放列表，频道带上优先级，播放列表中的分组加上分组前缀
+func (p *Provider) Fetch(location string) ([]dto.Channel, error) {
+	data, err := provider.ReadSource(p.client, location)
+	if err != nil {
+		return nil, fmt.Errorf("读取播放列表失败: %w", err)
+	}
+
+	channels := p.parse(string(data))
+	for i := range channels {
+		channels[i].Priority = p.priority
+		channels[i].Kind = dto.KindImport
+		if channels[i].Group != "" {
+			channels[i].Group = p.groupPrefix + channels[i].Group
+		}
+	}
+	return channels, nil
+}
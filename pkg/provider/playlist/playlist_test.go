@@ -0,0 +1,51 @@
+package playlist
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"iptv/pkg/config"
+	"iptv/pkg/provider"
+)
+
+func TestFetch(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"m3u": "#EXTM3U\n" +
+			"#EXTINF:-1 group-title=\"央视\",CCTV1\nhttp://example.com/cctv1.m3u8\n" +
+			"#EXTINF:-1,湖南卫视\nhttp://example.com/hunan.m3u8\n",
+		"txt": "央视,#genre#\nCCTV1,http://example.com/cctv1.m3u8\n",
+	}
+
+	for typ, content := range files {
+		file := filepath.Join(dir, "home."+typ)
+		if err := os.WriteFile(file, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		p, err := provider.New(config.ProviderConfig{Type: typ, Sources: []string{file}, Priority: 10, GroupPrefix: "自建-"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		sources, _ := p.Discover()
+		channels, err := p.Fetch(sources[0])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(channels) == 0 {
+			t.Fatalf("%s: no channels", typ)
+		}
+		if channels[0].Group != "自建-央视" || channels[0].Priority != 10 {
+			t.Errorf("%s: channel 0 = %+v", typ, channels[0])
+		}
+		// 没有分组的频道交给自动分组
+		if len(channels) > 1 && channels[1].Group != "" {
+			t.Errorf("%s: channel 1 group = %q, want empty", typ, channels[1].Group)
+		}
+	}
+
+	if _, err := provider.New(config.ProviderConfig{Type: "m3u", Name: "empty"}); err == nil {
+		t.Error("provider without sources should fail")
+	}
+}
@@ -0,0 +1,117 @@
+package provider
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"iptv/dto"
+	"iptv/pkg/config"
+	httppkg "iptv/pkg/http"
+)
+
+// Provider 频道来源
+type Provider interface {
+	// Name 来源名称，用于日志和统计
+	Name() string
+	// Discover 发现需要抓取的地址（例如组播源的频道列表页）
+	Discover() ([]string, error)
+	// Fetch 抓取单个地址中的频道
+	Fetch(sourceURL string) ([]dto.Channel, error)
+}
+
+// CookieUser 使用cookie请求的来源实现该接口，遇到验证页面说明cookie已过期
+type CookieUser interface {
+	UsesCookie() bool
+}
+
+// UsesCookie 来源是否使用cookie请求
+func UsesCookie(p Provider) bool {
+	c, ok := p.(CookieUser)
+	return ok && c.UsesCookie()
+}
+
+// Factory 根据配置创建来源
+type Factory func(cfg config.ProviderConfig) (Provider, error)
+
+var (
+	mu       sync.RWMutex
+	registry = make(map[string]Factory) // 类型 -> 工厂
+)
+
+// Register 注册来源类型，通常在来源包的init中调用
+func Register(typ string, factory Factory) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if _, ok := registry[typ]; ok {
+		panic("provider: 重复注册来源类型 " + typ)
+	}
+	registry[typ] = factory
+}
+
+// New 按配置的类型创建来源
+func New(cfg config.ProviderConfig) (Provider, error) {
+	mu.RLock()
+	factory, ok := registry[cfg.Type]
+	mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("未知的来源类型: %s（可用: %v）", cfg.Type, Types())
+	}
+
+	if cfg.Name == "" {
+		cfg.Name = cfg.Type
+	}
+	return factory(cfg)
+}
+
+// Types 已注册的来源类型
+func Types() []string {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	types := make([]string, 0, len(registry))
+	for typ := range registry {
+		types = append(types, typ)
+	}
+	sort.Strings(types)
+	return types
+}
+
+// ReadURLs 读取地址文件，每行一个地址，跳过空行和#开头的注释行
+func ReadURLs(filename string) ([]string, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var urls []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line != "" && !strings.HasPrefix(line, "#") {
+			urls = append(urls, line)
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return urls, nil
+}
+
+// ReadSource 读取本地文件或http(s)地址的内容，client为空时使用默认客户端
+func ReadSource(client *httppkg.Client, location string) ([]byte, error) {
+	if strings.HasPrefix(location, "http://") || strings.HasPrefix(location, "https://") {
+		if client == nil {
+			client = httppkg.GetClient()
+		}
+		return client.GetBody(location, nil, "")
+	}
+	return os.ReadFile(location)
+}
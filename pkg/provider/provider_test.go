@@ -0,0 +1,71 @@
+package provider
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"iptv/dto"
+	"iptv/pkg/config"
+)
+
+type staticProvider struct {
+	name string
+}
+
+func (p *staticProvider) Name() string                { return p.name }
+func (p *staticProvider) Discover() ([]string, error) { return []string{"a"}, nil }
+func (p *staticProvider) Fetch(string) ([]dto.Channel, error) {
+	return []dto.Channel{{Name: "CCTV1", URL: "http://example.com/1"}}, nil
+}
+
+func TestRegistry(t *testing.T) {
+	Register("static", func(cfg config.ProviderConfig) (Provider, error) {
+		return &staticProvider{name: cfg.Name}, nil
+	})
+
+	p, err := New(config.ProviderConfig{Type: "static"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Name() != "static" {
+		t.Errorf("Name = %q, want type as default name", p.Name())
+	}
+
+	if _, err := New(config.ProviderConfig{Type: "missing"}); err == nil {
+		t.Error("unknown type should fail")
+	}
+}
+
+type cookieProvider struct {
+	staticProvider
+}
+
+func (p *cookieProvider) UsesCookie() bool { return true }
+
+func TestUsesCookie(t *testing.T) {
+	if UsesCookie(&staticProvider{}) {
+		t.Error("provider without UsesCookie should not use cookie")
+	}
+	if !UsesCookie(&cookieProvider{}) {
+		t.Error("cookie provider should use cookie")
+	}
+}
+
+func TestReadURLs(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "source.txt")
+	content := "# 注释\nhttps://a.example.com/1\n\n  https://b.example.com/2  \n"
+	if err := os.WriteFile(file, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	urls, err := ReadURLs(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"https://a.example.com/1", "https://b.example.com/2"}
+	if !reflect.DeepEqual(urls, want) {
+		t.Errorf("ReadURLs = %v, want %v", urls, want)
+	}
+}
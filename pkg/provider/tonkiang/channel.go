@@ -0,0 +1,237 @@
+package tonkiang
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+	"iptv/dto"
+	"iptv/pkg/config"
+	"iptv/pkg/html"
+	httppkg "iptv/pkg/http"
+	"iptv/pkg/log"
+)
+
+// FetchChannelsFromURL 从URL获取频道列表：从URL中的p参数开始逐页请求getall.php（酒店源为alllist.php），
+// 直到页面为空、内容与已获取的重复或达到maxPages，每页之间等待pageDelay。API与列表页在同一站点（可以是镜像站）
+func FetchChannelsFromURL(client *httppkg.Client, pageURL string, cookies string, maxPages int, pageDelay time.Duration) ([]dto.Channel, error) {
+	// 解析URL参数
+	parsedURL, err := url.Parse(pageURL)
+	if err != nil {
+		return nil, fmt.Errorf("解析URL失败: %v", err)
+	}
+
+	query := parsedURL.Query()
+	startPage, err := strconv.Atoi(query.Get("p"))
+	if err != nil || startPage < 1 {
+		startPage = 1
+	}
+	if maxPages <= 0 {
+		maxPages = 1
+	}
+	kind := sourceKind(pageURL)
+	site := siteURL(parsedURL)
+
+	// 构建API的URL
+	apiURL := func(page int) string {
+		if kind == dto.KindHotel {
+			return fmt.Sprintf(site+"/alllist.php?s=%s&c=false&p=%d", url.QueryEscape(query.Get("s")), page)
+		}
+		return fmt.Sprintf(site+"/getall.php?ip=%s&c=%s&tk=%s&p=%d",
+			url.QueryEscape(query.Get("ip")), url.QueryEscape(query.Get("c")), url.QueryEscape(query.Get("tk")), page)
+	}
+
+	var channels []dto.Channel
+	seen := make(map[string]bool)
+	for page := startPage; page < startPage+maxPages; page++ {
+		if page > startPage && pageDelay > 0 {
+			time.Sleep(pageDelay)
+		}
+
+		// 获取数据，后续页失败时保留已获取的频道
+		pageChannels, err := fetchChannelsFromAPI(client, apiURL(page), pageURL, cookies)
+		if err != nil {
+			// 验证页面说明cookie已过期，后续请求都会失败
+			if page == startPage || errors.Is(err, httppkg.ErrChallenge) {
+				return nil, err
+			}
+			log.Warn("获取第%d页失败: %v,URL:%s", page, err, pageURL)
+			break
+		}
+
+		added := 0
+		for _, ch := range pageChannels {
+			if seen[ch.URL] {
+				continue
+			}
+			seen[ch.URL] = true
+			ch.Page = page
+			ch.Kind = kind
+			channels = append(channels, ch)
+			added++
+		}
+		if added == 0 {
+			// 空页或与之前的页重复（超出最后一页时网站可能返回最后一页的内容）
+			break
+		}
+		log.Debug("第%d页: %d 个频道,URL:%s", page, added, pageURL)
+	}
+
+	// 如果API返回空数据，尝试从原始页面获取
+	if len(channels) == 0 {
+		channels, err = fetchChannelsFromPage(client, pageURL, cookies)
+		if err != nil {
+			return nil, fmt.Errorf("从原始页面获取数据失败: %w", err)
+		}
+		for i := range channels {
+			channels[i].Page = startPage
+			channels[i].Kind = kind
+		}
+	}
+
+	return channels, nil
+}
+
+// siteURL 列表页所在站点的地址，例如 https://tonkiang.us，相对地址使用默认站点
+func siteURL(pageURL *url.URL) string {
+	if pageURL.Scheme == "" || pageURL.Host == "" {
+		return baseURL
+	}
+	return pageURL.Scheme + "://" + pageURL.Host
+}
+
+// fetchChannelsFromAPI 从API获取频道数据
+func fetchChannelsFromAPI(client *httppkg.Client, apiURL string, refererURL string, cookies string) ([]dto.Channel, error) {
+	doc, err := html.FetchHTMLForAPI(client, apiURL, cookies, refererURL)
+	if err != nil {
+		return nil, err
+	}
+
+	// 保存debug HTML（如果启用debug模式）
+	cfg := config.GetConfig()
+	if cfg != nil && cfg.App.Debug {
+		htmlContent, _ := doc.Html()
+		debugFile := cfg.Output.Debug
+		if debugFile != "" {
+			// 确保输出目录存在
+			debugDir := filepath.Dir(debugFile)
+			if debugDir != "." && debugDir != "" {
+				_ = os.MkdirAll(debugDir, 0755)
+			}
+			os.WriteFile(debugFile, []byte(htmlContent), 0644)
+		}
+	}
+
+	return parseChannelsFromDoc(doc), nil
+}
+
+// fetchChannelsFromPage 从页面获取频道数据
+func fetchChannelsFromPage(client *httppkg.Client, pageURL string, cookies string) ([]dto.Channel, error) {
+	doc, err := html.FetchHTML(client, pageURL, cookies, pageURL)
+	if err != nil {
+		return nil, err
+	}
+
+	return parseChannelsFromDoc(doc), nil
+}
+
+// parseChannelsFromDoc 从goquery文档中解析频道
+func parseChannelsFromDoc(doc *goquery.Document) []dto.Channel {
+	var channels []dto.Channel
+
+	// 查找所有result块
+	doc.Find("div.result").Each(func(i int, s *goquery.Selection) {
+		// 提取频道名称
+		channelName := ""
+		tipDiv := s.Find("div.channel div.tip").First()
+		if tipDiv.Length() > 0 {
+			channelName = strings.TrimSpace(tipDiv.Text())
+		} else {
+			// 备用方法：从整个channel div提取
+			channelDiv := s.Find("div.channel").First()
+			if channelDiv.Length() > 0 {
+				channelName = strings.TrimSpace(channelDiv.Text())
+				// 移除HTML标签
+				channelName = cleanTextFromHTML(channelName)
+			}
+		}
+
+		// 跳过无效的频道名称
+		if channelName == "" ||
+			strings.Contains(channelName, "请使用搜索框") ||
+			strings.Contains(channelName, "验证") ||
+			strings.Contains(channelName, "来自") ||
+			strings.Contains(channelName, "组播源") {
+			return
+		}
+
+		// 提取URL
+		m3u8URL := ""
+
+		// 方法1: 从onclick属性中提取
+		s.Find("img[onclick*='copyto']").Each(func(i int, img *goquery.Selection) {
+			onclick, exists := img.Attr("onclick")
+			if exists {
+				// 提取 onclick=copyto('URL') 中的URL
+				if strings.Contains(onclick, "copyto('") {
+					start := strings.Index(onclick, "copyto('") + len("copyto('")
+					end := strings.Index(onclick[start:], "'")
+					if end > 0 {
+						m3u8URL = strings.TrimSpace(onclick[start : start+end])
+					}
+				}
+			}
+		})
+
+		// 方法2: 从m3u8 div中的td标签提取
+		if m3u8URL == "" {
+			s.Find("div.m3u8 td").Each(func(i int, td *goquery.Selection) {
+				text := strings.TrimSpace(td.Text())
+				if strings.HasPrefix(text, "http://") || strings.HasPrefix(text, "https://") {
+					// 提取第一个URL
+					parts := strings.Fields(text)
+					for _, part := range parts {
+						if strings.HasPrefix(part, "http://") || strings.HasPrefix(part, "https://") {
+							m3u8URL = part
+							break
+						}
+					}
+				}
+			})
+		}
+
+		// 验证URL格式
+		if !dto.IsValidURL(m3u8URL) {
+			return
+		}
+
+		if channelName != "" && m3u8URL != "" {
+			channels = append(channels, dto.Channel{
+				Name: channelName,
+				URL:  m3u8URL,
+			})
+		}
+	})
+
+	return channels
+}
+
+// cleanTextFromHTML 清理HTML文本
+func cleanTextFromHTML(text string) string {
+	// 移除常见的HTML标签内容
+	text = strings.ReplaceAll(text, "\n", " ")
+	text = strings.ReplaceAll(text, "\t", " ")
+
+	// 合并多个空格
+	spaceRegex := regexp.MustCompile(`\s+`)
+	text = spaceRegex.ReplaceAllString(text, " ")
+
+	return strings.TrimSpace(text)
+}
@@ -0,0 +1,87 @@
+package tonkiang
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"iptv/dto"
+	httppkg "iptv/pkg/http"
+)
+
+// channelPage 生成包含指定频道的API页面
+func channelPage(names ...string) string {
+	var builder strings.Builder
+	for _, name := range names {
+		fmt.Fprintf(&builder, `<div class="result"><div class="channel"><div class="tip">%s</div></div>`+
+			`<div class="m3u8"><table><tr><td>http://1.1.1.1:8000/rtp/%s</td></tr></table></div></div>`, name, name)
+	}
+	return builder.String()
+}
+
+func TestFetchChannelsFromURLPaging(t *testing.T) {
+	pages := map[string]map[int]string{
+		// 第4页为空
+		"empty": {2: channelPage("CCTV1", "CCTV2"), 3: channelPage("CCTV3"), 4: ""},
+		// 超出最后一页时返回最后一页的内容
+		"repeat": {2: channelPage("CCTV1", "CCTV2"), 3: channelPage("CCTV3"), 4: channelPage("CCTV3")},
+		"limit":  {2: channelPage("CCTV1"), 3: channelPage("CCTV2"), 4: channelPage("CCTV3"), 5: channelPage("CCTV4")},
+	}
+
+	var mu sync.Mutex
+	var requested []int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/getall.php" {
+			http.NotFound(w, r)
+			return
+		}
+		page, _ := strconv.Atoi(r.URL.Query().Get("p"))
+		mu.Lock()
+		requested = append(requested, page)
+		mu.Unlock()
+		_, _ = w.Write([]byte(pages[r.URL.Query().Get("ip")][page]))
+	}))
+	defer srv.Close()
+
+	client, err := httppkg.NewClient(httppkg.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		ip        string
+		maxPages  int
+		channels  string
+		requested string
+	}{
+		{"empty", 10, "CCTV1@2 CCTV2@2 CCTV3@3", "2 3 4"},
+		{"repeat", 10, "CCTV1@2 CCTV2@2 CCTV3@3", "2 3 4"},
+		{"limit", 3, "CCTV1@2 CCTV2@3 CCTV3@4", "2 3 4"},
+	}
+	for _, tt := range tests {
+		requested = nil
+		pageURL := srv.URL + "/channellist.html?ip=" + tt.ip + "&tk=aa&p=2"
+		channels, err := FetchChannelsFromURL(client, pageURL, "", tt.maxPages, 0)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.ip, err)
+		}
+
+		got := make([]string, len(channels))
+		for i, ch := range channels {
+			got[i] = fmt.Sprintf("%s@%d", ch.Name, ch.Page)
+			if ch.Kind != dto.KindMulticast {
+				t.Errorf("%s: %s Kind = %q", tt.ip, ch.Name, ch.Kind)
+			}
+		}
+		if strings.Join(got, " ") != tt.channels {
+			t.Errorf("%s: channels = %v, want %s", tt.ip, got, tt.channels)
+		}
+		if fmt.Sprint(requested) != "["+tt.requested+"]" {
+			t.Errorf("%s: requested pages = %v, want [%s]", tt.ip, requested, tt.requested)
+		}
+	}
+}
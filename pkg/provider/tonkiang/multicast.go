@@ -0,0 +1,324 @@
+package tonkiang
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"iptv/dto"
+	"iptv/pkg/html"
+	httppkg "iptv/pkg/http"
+	"iptv/pkg/log"
+)
+
+// MulticastSource 组播源/酒店源信息
+type MulticastSource struct {
+	IP   string // 组播源为IP，酒店源为IP:端口
+	URL  string // 频道列表页地址
+	Kind string // 源类型：dto.KindMulticast 或 dto.KindHotel
+
+	// 列表页显示的信息，未显示时为空值
+	Region       string // 地区，例如 广东省广州市
+	Province     string // 省份，例如 广东
+	ISP          string // 运营商，例如 电信
+	Online       bool   // 是否在线
+	Status       string // 列表页显示的状态，例如 暂时失效
+	FirstSeen    string // 首次发现时间
+	AliveDays    int    // 存活天数
+	ChannelCount int    // 频道数量
+}
+
+// Info 列表页显示的信息，例如 广东省广州市 电信 在线 频道86 存活120天
+func (s MulticastSource) Info() string {
+	var parts []string
+	for _, part := range []string{s.Region, s.ISP, s.Status} {
+		if part != "" {
+			parts = append(parts, part)
+		}
+	}
+	if s.ChannelCount > 0 {
+		parts = append(parts, fmt.Sprintf("频道%d", s.ChannelCount))
+	}
+	if s.AliveDays > 0 {
+		parts = append(parts, fmt.Sprintf("存活%d天", s.AliveDays))
+	}
+	if s.FirstSeen != "" {
+		parts = append(parts, "首次发现"+s.FirstSeen)
+	}
+	return strings.Join(parts, " ")
+}
+
+// ListOptions 源列表的获取选项
+type ListOptions struct {
+	Limit       int    // 最多获取的数量
+	SkipFirst   bool   // 跳过第一条（第一条可能是最新数据，获取内容有概率失败）
+	Province    string // 只保留地区包含该名称的源，例如 广东
+	ISP         string // 只保留该运营商的源，例如 电信
+	OnlineOnly  bool   // 只保留在线的源
+	MinChannels int    // 只保留频道数量不少于该值的源（列表页未显示数量时不过滤）
+}
+
+// Match 源是否满足过滤条件
+func (o ListOptions) Match(source MulticastSource) bool {
+	if o.Province != "" && !strings.Contains(source.Region, o.Province) {
+		return false
+	}
+	if o.ISP != "" && !strings.Contains(source.ISP, o.ISP) {
+		return false
+	}
+	if o.OnlineOnly && !source.Online {
+		return false
+	}
+	if o.MinChannels > 0 && source.ChannelCount > 0 && source.ChannelCount < o.MinChannels {
+		return false
+	}
+	return true
+}
+
+// listing 源列表页
+type listing struct {
+	path     string            // 列表页路径
+	selector string            // 频道列表链接的选择器
+	param    string            // 链接中表示源地址的参数
+	kind     string            // 源类型
+	accept   func(string) bool // 是否使用该链接，为空时全部使用
+}
+
+var (
+	// 组播源列表，只获取 p=2 的链接
+	// 格式: <div class="channel"><a href='channellist.html?ip=180.127.29.153&tk=03aae295&p=2' title="Channel List">
+	multicastListing = listing{
+		path:     "/iptvmulticast.php",
+		selector: "div.channel a[href*='channellist.html?ip=']",
+		param:    "ip",
+		kind:     dto.KindMulticast,
+		accept:   func(href string) bool { return strings.Contains(href, "p=2") },
+	}
+
+	// 酒店源列表
+	// 格式: <div class="channel"><a href='hotellist.html?s=39.152.196.178:9901&Y=1' title="Channel List">
+	hotelListing = listing{
+		path:     "/hoteliptv.php",
+		selector: "div.channel a[href*='hotellist.html?s=']",
+		param:    "s",
+		kind:     dto.KindHotel,
+	}
+)
+
+// FetchMulticastIPs 从iptvmulticast.php获取组播源列表
+func FetchMulticastIPs(client *httppkg.Client, cookies string, opts ListOptions) ([]MulticastSource, error) {
+	sources, err := fetchListing(client, multicastListing, cookies, opts)
+	if err != nil {
+		return nil, fmt.Errorf("获取组播源页面失败: %w", err)
+	}
+	return sources, nil
+}
+
+// FetchHotelIPs 从hoteliptv.php获取酒店源列表
+func FetchHotelIPs(client *httppkg.Client, cookies string, opts ListOptions) ([]MulticastSource, error) {
+	sources, err := fetchListing(client, hotelListing, cookies, opts)
+	if err != nil {
+		return nil, fmt.Errorf("获取酒店源页面失败: %w", err)
+	}
+	return sources, nil
+}
+
+// fetchListing 获取列表页中的源
+func fetchListing(client *httppkg.Client, l listing, cookies string, opts ListOptions) ([]MulticastSource, error) {
+	doc, err := html.FetchHTML(client, baseURL+l.path, cookies, baseURL+"/?")
+	if err != nil {
+		return nil, err
+	}
+	return parseListing(doc, l, opts), nil
+}
+
+// parseListing 解析列表页中的源及其信息，跳过第一条后按条件过滤，最多保留opts.Limit个
+func parseListing(doc *goquery.Document, l listing, opts ListOptions) []MulticastSource {
+	var sources []MulticastSource
+	skipFirst := opts.SkipFirst
+
+	doc.Find(l.selector).Each(func(i int, s *goquery.Selection) {
+		if opts.Limit > 0 && len(sources) >= opts.Limit {
+			return
+		}
+
+		href, exists := s.Attr("href")
+		if !exists {
+			return
+		}
+
+		if l.accept != nil && !l.accept(href) {
+			return
+		}
+
+		// 跳过第一条
+		if skipFirst {
+			skipFirst = false
+			return
+		}
+
+		// 提取源地址
+		ip := extractParamFromHref(href, l.param)
+		if ip == "" {
+			return
+		}
+
+		// 构建完整URL
+		fullURL := buildFullURL(href)
+		if fullURL == "" {
+			return
+		}
+
+		source := MulticastSource{
+			IP:   ip,
+			URL:  fullURL,
+			Kind: l.kind,
+		}
+		parseSourceInfo(&source, nodeText(listingItem(s)))
+
+		if !opts.Match(source) {
+			log.Debug("跳过%s源 %s（%s %s %s）", dto.KindLabel(l.kind), ip, source.Region, source.ISP, source.Status)
+			return
+		}
+		sources = append(sources, source)
+	})
+
+	return sources
+}
+
+// listingItem 链接所在的列表项，用于读取该源的其他信息
+func listingItem(link *goquery.Selection) *goquery.Selection {
+	if item := link.Closest("div.result"); item.Length() > 0 {
+		return item
+	}
+	// 没有result块时使用channel块的上一级
+	return link.Closest("div.channel").Parent()
+}
+
+// nodeText 元素中的文字，相邻元素的文字之间用空格分隔（Text()会直接拼接）
+func nodeText(sel *goquery.Selection) string {
+	var parts []string
+	sel.Contents().Each(func(i int, child *goquery.Selection) {
+		if goquery.NodeName(child) == "#text" {
+			parts = append(parts, child.Text())
+		} else {
+			parts = append(parts, nodeText(child))
+		}
+	})
+	return strings.Join(parts, " ")
+}
+
+var (
+	ispPattern       = regexp.MustCompile(`(电信|联通|移动|广电|铁通|教育网)`)
+	regionPattern    = regexp.MustCompile(`([\p{Han}]{2,}?(?:省|市|自治区|特别行政区|地区|州|盟|县|区))+`)
+	provincePattern  = regexp.MustCompile(`^(内蒙古|广西|西藏|宁夏|新疆|北京|上海|天津|重庆|香港|澳门|[\p{Han}]{2,3}?)(?:省|市|自治区|壮族自治区|回族自治区|维吾尔自治区|特别行政区)`)
+	channelsPattern  = regexp.MustCompile(`(\d+)\s*(?:个)?频道|频道(?:数|数量)?[:：]?\s*(\d+)`)
+	aliveDaysPattern = regexp.MustCompile(`存活\s*[:：]?\s*(\d+)\s*天`)
+	firstSeenPattern = regexp.MustCompile(`\d{4}-\d{1,2}-\d{1,2}(?:\s+\d{1,2}:\d{2}(?::\d{2})?)?`)
+)
+
+// parseSourceInfo 从列表项的文字中解析地区、运营商、在线状态、首次发现时间、存活天数和频道数量
+func parseSourceInfo(source *MulticastSource, text string) {
+	text = cleanTextFromHTML(text)
+
+	if isp := ispPattern.FindString(text); isp != "" {
+		source.ISP = isp
+	}
+	if region := regionPattern.FindString(text); region != "" {
+		source.Region = region
+		if m := provincePattern.FindStringSubmatch(region); m != nil {
+			source.Province = m[1]
+		}
+	}
+
+	switch {
+	case strings.Contains(text, "失效"), strings.Contains(text, "离线"), strings.Contains(text, "下线"):
+		source.Status = statusText(text, "暂时失效", "失效", "离线", "下线")
+	case strings.Contains(text, "在线"), strings.Contains(text, "上线"), strings.Contains(text, "存活"):
+		source.Online = true
+		source.Status = statusText(text, "新上线", "暂时在线", "在线", "存活", "上线")
+	}
+
+	if m := firstSeenPattern.FindString(text); m != "" {
+		source.FirstSeen = m
+	}
+	if m := aliveDaysPattern.FindStringSubmatch(text); m != nil {
+		source.AliveDays, _ = strconv.Atoi(m[1])
+	}
+	if m := channelsPattern.FindStringSubmatch(text); m != nil {
+		count := m[1]
+		if count == "" {
+			count = m[2]
+		}
+		source.ChannelCount, _ = strconv.Atoi(count)
+	}
+}
+
+// statusText 返回文字中第一个出现的状态词
+func statusText(text string, words ...string) string {
+	for _, word := range words {
+		if strings.Contains(text, word) {
+			return word
+		}
+	}
+	return ""
+}
+
+// extractParamFromHref 从href中提取参数，例如 channellist.html?ip=221.220.131.129&tk=c188c009&p=2 中的ip
+func extractParamFromHref(href string, param string) string {
+	parsed, err := url.Parse(href)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(parsed.Query().Get(param))
+}
+
+// buildFullURL 构建完整的URL
+func buildFullURL(href string) string {
+	// 如果已经是完整URL，直接返回
+	if strings.HasPrefix(href, "http://") || strings.HasPrefix(href, "https://") {
+		return href
+	}
+
+	// 构建完整URL
+	if strings.HasPrefix(href, "channellist.html") || strings.HasPrefix(href, "hotellist.html") {
+		return baseURL + "/" + href
+	}
+
+	return ""
+}
+
+// UpdateSourceFile 更新来源地址文件（默认config/source.txt）
+func UpdateSourceFile(sources []MulticastSource, filePath string) error {
+	// 构建文件内容，列表页显示的信息写在地址前的注释行
+	var lines []string
+	for _, source := range sources {
+		if info := source.Info(); info != "" {
+			lines = append(lines, "# "+info)
+		}
+		lines = append(lines, source.URL)
+	}
+
+	content := strings.Join(lines, "\n") + "\n"
+
+	// 写入文件
+	err := os.WriteFile(filePath, []byte(content), 0644)
+	if err != nil {
+		return fmt.Errorf("写入%s失败: %v", filepath.Base(filePath), err)
+	}
+
+	return nil
+}
+
+// sourceKind 根据频道列表页地址判断源类型
+func sourceKind(pageURL string) string {
+	if strings.Contains(pageURL, "hotellist.html") {
+		return dto.KindHotel
+	}
+	return dto.KindMulticast
+}
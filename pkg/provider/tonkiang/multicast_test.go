@@ -0,0 +1,59 @@
+package tonkiang
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/PuerkitoBio/goquery"
+	"iptv/dto"
+)
+
+const multicastPage = `<div class="result">
+<div class="channel"><a href='channellist.html?ip=1.1.1.1&tk=aa&p=2'><b>1.1.1.1</b></a></div>
+<div>12 频道</div><i>2025-01-02 上线 新上线</i><i>广东省广州市 电信</i></div>
+<div class="result">
+<div class="channel"><a href='channellist.html?ip=2.2.2.2&tk=bb&p=2'><b>2.2.2.2</b></a></div>
+<div>86 频道</div><i>2024-10-01 上线 存活 120 天</i><i>广东省深圳市 电信</i></div>
+<div class="result">
+<div class="channel"><a href='channellist.html?ip=3.3.3.3&tk=cc&p=2'><b>3.3.3.3</b></a></div>
+<div>40 频道</div><i>暂时失效</i><i>广东省佛山市 电信</i></div>
+<div class="result">
+<div class="channel"><a href='channellist.html?ip=4.4.4.4&tk=dd&p=2'><b>4.4.4.4</b></a></div>
+<div>60 频道</div><i>存活 30 天</i><i>内蒙古自治区呼和浩特市 联通</i></div>
+<div class="result">
+<div class="channel"><a href='channellist.html?ip=5.5.5.5&tk=ee&p=1'><b>5.5.5.5</b></a></div></div>`
+
+func TestParseListing(t *testing.T) {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(multicastPage))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	all := parseListing(doc, multicastListing, ListOptions{})
+	if len(all) != 4 {
+		t.Fatalf("got %d sources, want 4 (p=1 skipped)", len(all))
+	}
+	want := MulticastSource{
+		IP: "2.2.2.2", URL: baseURL + "/channellist.html?ip=2.2.2.2&tk=bb&p=2", Kind: dto.KindMulticast,
+		Region: "广东省深圳市", Province: "广东", ISP: "电信", Online: true, Status: "存活",
+		FirstSeen: "2024-10-01", AliveDays: 120, ChannelCount: 86,
+	}
+	if all[1] != want {
+		t.Errorf("source = %+v\nwant %+v", all[1], want)
+	}
+	if all[2].Online || all[2].Status != "暂时失效" {
+		t.Errorf("offline source = %+v", all[2])
+	}
+	if all[3].Province != "内蒙古" || all[3].ISP != "联通" {
+		t.Errorf("region = %q/%q, isp = %q", all[3].Region, all[3].Province, all[3].ISP)
+	}
+
+	// 跳过第一条后按条件过滤，再取前limit个
+	filtered := parseListing(doc, multicastListing, ListOptions{Limit: 1, SkipFirst: true, Province: "广东", ISP: "电信", OnlineOnly: true})
+	if len(filtered) != 1 || filtered[0].IP != "2.2.2.2" {
+		t.Errorf("filtered = %+v", filtered)
+	}
+	if got := parseListing(doc, multicastListing, ListOptions{MinChannels: 50}); len(got) != 2 {
+		t.Errorf("MinChannels: got %d sources, want 2", len(got))
+	}
+}
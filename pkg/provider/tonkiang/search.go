@@ -0,0 +1,124 @@
+package tonkiang
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"iptv/dto"
+	"iptv/pkg/config"
+	"iptv/pkg/html"
+	httppkg "iptv/pkg/http"
+	"iptv/pkg/log"
+	"iptv/pkg/provider"
+)
+
+const defaultSearchPages = 3
+
+// SearchProvider 按频道名称关键字搜索（组播源中很少包含体育、付费频道）
+type SearchProvider struct {
+	name      string
+	client    *httppkg.Client
+	keywords  []string
+	maxPages  int
+	pageDelay time.Duration
+	priority  int
+}
+
+// NewSearch 创建关键字搜索来源
+func NewSearch(cfg config.ProviderConfig) (provider.Provider, error) {
+	if len(cfg.Keywords) == 0 {
+		return nil, fmt.Errorf("来源%s没有配置keywords", cfg.Name)
+	}
+
+	client, err := httppkg.NewClient(httppkg.Options{Profile: cfg.Profile, Proxies: cfg.Proxies})
+	if err != nil {
+		return nil, fmt.Errorf("来源%s: %v", cfg.Name, err)
+	}
+
+	p := &SearchProvider{
+		name:      cfg.Name,
+		client:    client,
+		keywords:  cfg.Keywords,
+		maxPages:  cfg.MaxPages,
+		pageDelay: time.Duration(cfg.PageDelay) * time.Second,
+		priority:  cfg.Priority,
+	}
+	if p.maxPages <= 0 {
+		p.maxPages = defaultSearchPages
+	}
+	return p, nil
+}
+
+// Name 来源名称
+func (p *SearchProvider) Name() string {
+	return p.name
+}
+
+// Discover 返回配置的关键字，每个关键字单独抓取
+func (p *SearchProvider) Discover() ([]string, error) {
+	return p.keywords, nil
+}
+
+// Fetch 搜索关键字并逐页抓取结果，直到页面为空、与已抓取的结果重复或达到页数上限
+func (p *SearchProvider) Fetch(keyword string) ([]dto.Channel, error) {
+	var channels []dto.Channel
+	seen := make(map[string]bool)
+	referer := baseURL + "/"
+
+	for page := 1; page <= p.maxPages; page++ {
+		if page > 1 && p.pageDelay > 0 {
+			time.Sleep(p.pageDelay)
+		}
+
+		searchURL := fmt.Sprintf("%s/?page=%d&iqtv=%s", baseURL, page, url.QueryEscape(keyword))
+		doc, err := html.FetchHTMLForAPI(p.client, searchURL, cookies(), referer)
+		if err != nil {
+			if page == 1 || errors.Is(err, httppkg.ErrChallenge) {
+				return nil, fmt.Errorf("搜索%s失败: %w", keyword, err)
+			}
+			log.Warn("搜索%s第%d页失败: %v", keyword, page, err)
+			break
+		}
+		referer = searchURL
+
+		added := 0
+		for _, ch := range parseChannelsFromDoc(doc) {
+			if seen[ch.URL] {
+				continue
+			}
+			seen[ch.URL] = true
+			added++
+
+			// 搜索结果是模糊匹配，只保留名称包含关键字的频道
+			if !matchKeyword(ch.Name, keyword) {
+				continue
+			}
+			ch.Priority = p.priority
+			ch.Page = page
+			ch.Kind = dto.KindSearch
+			channels = append(channels, ch)
+		}
+		log.Debug("搜索%s第%d页: %d 个新结果", keyword, page, added)
+		if added == 0 {
+			break
+		}
+	}
+
+	return channels, nil
+}
+
+// UsesCookie 请求使用cookie（验证页面说明cookie已过期）
+func (p *SearchProvider) UsesCookie() bool {
+	return true
+}
+
+// matchKeyword 频道名称是否包含关键字（忽略大小写、空格和横线）
+func matchKeyword(name, keyword string) bool {
+	normalize := func(s string) string {
+		return strings.ToUpper(strings.NewReplacer(" ", "", "-", "").Replace(s))
+	}
+	return strings.Contains(normalize(name), normalize(keyword))
+}
@@ -0,0 +1,35 @@
+package tonkiang
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+func TestParseSearchResults(t *testing.T) {
+	page := `<div class="result"><div class="channel"><div class="tip">CCTV5+ 体育赛事</div></div>
+<div class="m3u8"><table><tr><td><img onclick="copyto('http://1.2.3.4:8080/hls/5/index.m3u8')"></td></tr></table></div></div>
+<div class="result"><div class="channel"><div class="tip">CCTV5</div></div>
+<div class="m3u8"><table><tr><td>http://1.2.3.4:8080/hls/4/index.m3u8 高清</td></tr></table></div></div>
+<div class="result"><div class="channel"><div class="tip">请使用搜索框</div></div></div>`
+
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(page))
+	if err != nil {
+		t.Fatal(err)
+	}
+	channels := parseChannelsFromDoc(doc)
+	if len(channels) != 2 {
+		t.Fatalf("got %d channels, want 2: %+v", len(channels), channels)
+	}
+	if channels[0].URL != "http://1.2.3.4:8080/hls/5/index.m3u8" || channels[1].URL != "http://1.2.3.4:8080/hls/4/index.m3u8" {
+		t.Errorf("channels = %+v", channels)
+	}
+
+	if !matchKeyword(channels[0].Name, "cctv-5+") {
+		t.Errorf("%q should match cctv-5+", channels[0].Name)
+	}
+	if matchKeyword(channels[1].Name, "CCTV5+") {
+		t.Errorf("%q should not match CCTV5+", channels[1].Name)
+	}
+}
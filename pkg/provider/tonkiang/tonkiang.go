@@ -0,0 +1,171 @@
+package tonkiang
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"iptv/dto"
+	"iptv/pkg/bark"
+	"iptv/pkg/config"
+	"iptv/pkg/cookie"
+	httppkg "iptv/pkg/http"
+	"iptv/pkg/log"
+	"iptv/pkg/provider"
+)
+
+const (
+	baseURL           = "https://tonkiang.us"
+	defaultSourceFile = "config/source.txt"
+	defaultLimit      = 5
+	defaultMaxPages   = 10
+)
+
+func init() {
+	provider.Register("tonkiang", New)
+	provider.Register("tonkiang-search", NewSearch)
+}
+
+// Provider tonkiang.us 组播源/酒店源：从源列表发现频道列表页，再抓取每个列表页的频道
+type Provider struct {
+	name       string
+	client     *httppkg.Client
+	discover   bool
+	kinds      []string
+	list       ListOptions
+	sourceFile string
+	maxPages   int
+	pageDelay  time.Duration
+}
+
+// New 创建tonkiang来源
+func New(cfg config.ProviderConfig) (provider.Provider, error) {
+	client, err := httppkg.NewClient(httppkg.Options{Profile: cfg.Profile, Proxies: cfg.Proxies})
+	if err != nil {
+		return nil, fmt.Errorf("来源%s: %v", cfg.Name, err)
+	}
+
+	p := &Provider{
+		name:       cfg.Name,
+		client:     client,
+		discover:   cfg.Discover,
+		kinds:      cfg.Kinds,
+		sourceFile: cfg.SourceFile,
+		maxPages:   cfg.MaxPages,
+		pageDelay:  time.Duration(cfg.PageDelay) * time.Second,
+		list: ListOptions{
+			Limit:       cfg.Limit,
+			SkipFirst:   cfg.SkipFirst == nil || *cfg.SkipFirst,
+			Province:    cfg.Province,
+			ISP:         cfg.ISP,
+			OnlineOnly:  cfg.OnlineOnly,
+			MinChannels: cfg.MinChannels,
+		},
+	}
+	if len(p.kinds) == 0 {
+		p.kinds = []string{dto.KindMulticast}
+	}
+	for _, kind := range p.kinds {
+		if kind != dto.KindMulticast && kind != dto.KindHotel {
+			return nil, fmt.Errorf("来源%s的kinds只能是%s或%s: %s", cfg.Name, dto.KindMulticast, dto.KindHotel, kind)
+		}
+	}
+	if p.list.Limit <= 0 {
+		p.list.Limit = defaultLimit
+	}
+	if p.sourceFile == "" {
+		p.sourceFile = defaultSourceFile
+	}
+	if p.maxPages <= 0 {
+		p.maxPages = defaultMaxPages
+	}
+	return p, nil
+}
+
+// Name 来源名称
+func (p *Provider) Name() string {
+	return p.name
+}
+
+// Discover 从组播源/酒店源列表更新来源文件（失败时使用文件中已有的地址），返回文件中的所有地址
+func (p *Provider) Discover() ([]string, error) {
+	if p.discover {
+		err := p.updateSourceFile()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	urls, err := provider.ReadURLs(p.sourceFile)
+	if err != nil {
+		return nil, fmt.Errorf("读取【%s】失败: %v", p.sourceFile, err)
+	}
+	return urls, nil
+}
+
+// updateSourceFile 按配置的源类型获取最新的源，获取失败的类型保留文件中已有的地址
+// 遇到验证页面时返回错误（cookie已过期，继续抓取没有意义）
+func (p *Provider) updateSourceFile() error {
+	var sources []MulticastSource
+	failed := make(map[string]bool)
+	for _, kind := range p.kinds {
+		fetch := FetchMulticastIPs
+		if kind == dto.KindHotel {
+			fetch = FetchHotelIPs
+		}
+
+		found, err := fetch(p.client, cookies(), p.list)
+		if errors.Is(err, httppkg.ErrChallenge) {
+			return err
+		}
+		if err != nil {
+			log.Warn("获取%s源失败: %v", dto.KindLabel(kind), err)
+			_ = bark.Push("IPTV", "获取%s源失败: %v", dto.KindLabel(kind), err.Error())
+			failed[kind] = true
+			continue
+		}
+		for _, source := range found {
+			log.Info("%s源 %s: %s", dto.KindLabel(kind), source.IP, source.Info())
+		}
+		log.Info("成功获取 %d 个%s源", len(found), dto.KindLabel(kind))
+		_ = bark.Push("IPTV", "成功获取 %d 个%s源", len(found), dto.KindLabel(kind))
+		sources = append(sources, found...)
+	}
+
+	if len(sources) == 0 {
+		log.Info("将使用%s中的现有URL", p.sourceFile)
+		return nil
+	}
+
+	if len(failed) > 0 {
+		existing, _ := provider.ReadURLs(p.sourceFile)
+		for _, u := range existing {
+			if kind := sourceKind(u); failed[kind] {
+				sources = append(sources, MulticastSource{URL: u, Kind: kind})
+			}
+		}
+	}
+
+	err := UpdateSourceFile(sources, p.sourceFile)
+	if err != nil {
+		log.Warn("更新%s失败: %v", p.sourceFile, err)
+	} else {
+		log.Info("已更新%s", p.sourceFile)
+	}
+	return nil
+}
+
+// Fetch 抓取频道列表页中的频道
+func (p *Provider) Fetch(sourceURL string) ([]dto.Channel, error) {
+	return FetchChannelsFromURL(p.client, sourceURL, cookies(), p.maxPages, p.pageDelay)
+}
+
+// UsesCookie 请求使用cookie（验证页面说明cookie已过期）
+func (p *Provider) UsesCookie() bool {
+	return true
+}
+
+// cookies 当前的cookie（cookie文件更新后自动使用新的cookie）
+func cookies() string {
+	return cookie.Get()
+}
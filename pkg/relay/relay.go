@@ -0,0 +1,236 @@
+package relay
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"iptv/pkg/log"
+)
+
+const (
+	maxDatagram   = 65536 // UDP包最大长度
+	clientBuffer  = 512   // 每个客户端缓存的包数，超过时丢弃（慢客户端不影响其他客户端）
+	readTimeout   = 5 * time.Second
+	tsContentType = "video/mp2t"
+)
+
+// Relay 组播转HTTP（兼容udpxy的 /udp/组播地址:端口 和 /rtp/组播地址:端口）
+type Relay struct {
+	iface *net.Interface
+
+	mu     sync.Mutex
+	groups map[string]*group // 协议+组播地址 -> 组
+}
+
+// group 一个组播组，所有客户端共享同一个接收socket
+type group struct {
+	key     string
+	conn    *net.UDPConn
+	rtp     bool
+	mu      sync.Mutex
+	clients map[chan []byte]struct{}
+}
+
+// New 创建转发器，ifaceName为加入组播的网卡名称，为空时使用系统默认网卡
+func New(ifaceName string) (*Relay, error) {
+	r := &Relay{groups: make(map[string]*group)}
+	if ifaceName != "" {
+		iface, err := net.InterfaceByName(ifaceName)
+		if err != nil {
+			return nil, fmt.Errorf("网卡%s不存在: %v", ifaceName, err)
+		}
+		r.iface = iface
+	}
+	return r, nil
+}
+
+// ServeHTTP 处理 /udp/239.x.x.x:port 和 /rtp/239.x.x.x:port
+func (r *Relay) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	proto, addr, err := parsePath(req.URL.Path)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	g, ch, err := r.subscribe(proto, addr)
+	if err != nil {
+		log.Warn("加入组播失败: %s://%s (%v)", proto, addr, err)
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	defer r.unsubscribe(g, ch)
+
+	log.Info("组播转发开始: %s://%s -> %s", proto, addr, req.RemoteAddr)
+	w.Header().Set("Content-Type", tsContentType)
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	if req.Method == http.MethodHead {
+		return
+	}
+
+	// 立即发送响应头，客户端不必等到第一个组播包
+	flusher, _ := w.(http.Flusher)
+	if flusher != nil {
+		flusher.Flush()
+	}
+	for {
+		select {
+		case <-req.Context().Done():
+			log.Info("组播转发结束: %s://%s -> %s", proto, addr, req.RemoteAddr)
+			return
+		case data, ok := <-ch:
+			if !ok {
+				return
+			}
+			if _, err := w.Write(data); err != nil {
+				return
+			}
+			if flusher != nil && len(ch) == 0 {
+				flusher.Flush()
+			}
+		}
+	}
+}
+
+// parsePath 解析请求路径，返回协议和组播地址
+func parsePath(urlPath string) (string, *net.UDPAddr, error) {
+	parts := strings.SplitN(strings.TrimPrefix(urlPath, "/"), "/", 2)
+	if len(parts) != 2 || (parts[0] != "udp" && parts[0] != "rtp") {
+		return "", nil, fmt.Errorf("路径格式应为 /udp/组播地址:端口 或 /rtp/组播地址:端口")
+	}
+
+	// 兼容 /rtp/@239.1.1.1:5000 写法
+	addr, err := net.ResolveUDPAddr("udp4", strings.TrimPrefix(parts[1], "@"))
+	if err != nil {
+		return "", nil, fmt.Errorf("组播地址无效: %v", err)
+	}
+	if !addr.IP.IsMulticast() {
+		return "", nil, fmt.Errorf("%s 不是组播地址", addr.IP)
+	}
+	return parts[0], addr, nil
+}
+
+// subscribe 加入组播组（已加入时复用），返回客户端的数据通道
+func (r *Relay) subscribe(proto string, addr *net.UDPAddr) (*group, chan []byte, error) {
+	key := proto + "://" + addr.String()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	g, ok := r.groups[key]
+	if !ok {
+		conn, err := net.ListenMulticastUDP("udp4", r.iface, addr)
+		if err != nil {
+			return nil, nil, err
+		}
+		_ = conn.SetReadBuffer(4 << 20)
+
+		g = &group{key: key, conn: conn, rtp: proto == "rtp", clients: make(map[chan []byte]struct{})}
+		r.groups[key] = g
+		go r.receive(g)
+	}
+
+	ch := make(chan []byte, clientBuffer)
+	g.mu.Lock()
+	g.clients[ch] = struct{}{}
+	g.mu.Unlock()
+	return g, ch, nil
+}
+
+// unsubscribe 客户端断开，最后一个客户端断开时离开组播组
+func (r *Relay) unsubscribe(g *group, ch chan []byte) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	g.mu.Lock()
+	delete(g.clients, ch)
+	empty := len(g.clients) == 0
+	g.mu.Unlock()
+
+	if empty && r.groups[g.key] == g {
+		delete(r.groups, g.key)
+		g.conn.Close()
+	}
+}
+
+// receive 接收组播数据并分发给所有客户端
+func (r *Relay) receive(g *group) {
+	defer r.closeGroup(g)
+
+	buf := make([]byte, maxDatagram)
+	for {
+		_ = g.conn.SetReadDeadline(time.Now().Add(readTimeout))
+		n, _, err := g.conn.ReadFromUDP(buf)
+		if err != nil {
+			if ne, ok := err.(net.Error); ok && ne.Timeout() {
+				log.Warn("组播%s超过%v没有数据", g.key, readTimeout)
+				continue
+			}
+			return // socket已关闭
+		}
+
+		payload := buf[:n]
+		if g.rtp {
+			payload = StripRTP(payload)
+		}
+		if len(payload) == 0 {
+			continue
+		}
+		data := append([]byte(nil), payload...)
+
+		g.mu.Lock()
+		for ch := range g.clients {
+			select {
+			case ch <- data:
+			default:
+				// 客户端太慢，丢弃该包
+			}
+		}
+		g.mu.Unlock()
+	}
+}
+
+// closeGroup 接收结束时通知所有客户端
+func (r *Relay) closeGroup(g *group) {
+	r.mu.Lock()
+	if r.groups[g.key] == g {
+		delete(r.groups, g.key)
+		g.conn.Close()
+	}
+	r.mu.Unlock()
+
+	g.mu.Lock()
+	for ch := range g.clients {
+		close(ch)
+		delete(g.clients, ch)
+	}
+	g.mu.Unlock()
+}
+
+// StripRTP 去掉RTP头（含CSRC、扩展头和填充），不是RTP包（例如裸TS）时原样返回
+func StripRTP(packet []byte) []byte {
+	if len(packet) < 12 || packet[0] == 0x47 || packet[0]>>6 != 2 {
+		return packet
+	}
+
+	offset := 12 + 4*int(packet[0]&0x0f)
+	if packet[0]&0x10 != 0 { // 扩展头
+		if len(packet) < offset+4 {
+			return nil
+		}
+		offset += 4 + 4*(int(packet[offset+2])<<8|int(packet[offset+3]))
+	}
+
+	end := len(packet)
+	if packet[0]&0x20 != 0 { // 填充
+		end -= int(packet[len(packet)-1])
+	}
+	if offset >= end {
+		return nil
+	}
+	return packet[offset:end]
+}
@@ -0,0 +1,142 @@
+package relay
+
+import (
+	"bytes"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestStripRTP(t *testing.T) {
+	ts := bytes.Repeat([]byte{0x47, 0x00, 0x11, 0x10}, 47)
+
+	plain := append([]byte{0x80, 0x21, 0x00, 0x01, 0, 0, 0, 0, 0, 0, 0, 1}, ts...)
+	if got := StripRTP(plain); !bytes.Equal(got, ts) {
+		t.Errorf("plain RTP: got %d bytes", len(got))
+	}
+
+	// 1个CSRC + 扩展头（1个字）+ 2字节填充
+	ext := []byte{0xb1, 0x21, 0x00, 0x01, 0, 0, 0, 0, 0, 0, 0, 1, 0, 0, 0, 2, 0xbe, 0xde, 0x00, 0x01, 1, 2, 3, 4}
+	ext = append(ext, ts...)
+	ext = append(ext, 0x00, 0x02)
+	if got := StripRTP(ext); !bytes.Equal(got, ts) {
+		t.Errorf("RTP with CSRC/extension/padding: got %d bytes", len(got))
+	}
+
+	if got := StripRTP(ts); !bytes.Equal(got, ts) {
+		t.Errorf("raw TS should pass through")
+	}
+}
+
+func TestParsePath(t *testing.T) {
+	tests := []struct {
+		path  string
+		proto string
+		ok    bool
+	}{
+		{"/udp/239.3.1.241:8000", "udp", true},
+		{"/rtp/@239.3.1.241:8000", "rtp", true},
+		{"/rtp/192.168.1.1:8000", "", false},
+		{"/http/239.3.1.241:8000", "", false},
+		{"/udp/", "", false},
+	}
+	for _, tt := range tests {
+		proto, _, err := parsePath(tt.path)
+		if (err == nil) != tt.ok || proto != tt.proto {
+			t.Errorf("parsePath(%q) = %q, %v", tt.path, proto, err)
+		}
+	}
+}
+
+func TestRelayMulticast(t *testing.T) {
+	r, err := New("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewServer(r)
+	defer srv.Close()
+
+	group := "239.255.42.99:45678"
+	addr, _ := net.ResolveUDPAddr("udp4", group)
+	sender, err := net.DialUDP("udp4", nil, addr)
+	if err != nil {
+		t.Skipf("无法发送组播: %v", err)
+	}
+	defer sender.Close()
+
+	ts := bytes.Repeat([]byte{0x47, 0x01, 0x00, 0x10}, 47)
+	packet := append([]byte{0x80, 0x21, 0x00, 0x01, 0, 0, 0, 0, 0, 0, 0, 1}, ts...)
+
+	// 两个客户端共享同一个组播接收端
+	var bodies []io.ReadCloser
+	for i := 0; i < 2; i++ {
+		resp, err := http.Get(srv.URL + "/rtp/" + group)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.StatusCode != http.StatusOK {
+			resp.Body.Close()
+			t.Skipf("无法加入组播: HTTP %d", resp.StatusCode)
+		}
+		if ct := resp.Header.Get("Content-Type"); ct != tsContentType {
+			t.Errorf("Content-Type = %q", ct)
+		}
+		defer resp.Body.Close()
+		bodies = append(bodies, resp.Body)
+	}
+	r.mu.Lock()
+	if n := len(r.groups); n != 1 {
+		t.Errorf("groups = %d, want 1", n)
+	}
+	r.mu.Unlock()
+
+	stop := make(chan struct{})
+	defer close(stop)
+	go func() {
+		for {
+			select {
+			case <-stop:
+				return
+			case <-time.After(20 * time.Millisecond):
+				_, _ = sender.Write(packet)
+			}
+		}
+	}()
+
+	for i, body := range bodies {
+		buf := make([]byte, len(ts))
+		done := make(chan error, 1)
+		go func() {
+			_, err := io.ReadFull(body, buf)
+			done <- err
+		}()
+		select {
+		case err := <-done:
+			if err != nil {
+				t.Fatalf("client %d: %v", i, err)
+			}
+		case <-time.After(3 * time.Second):
+			t.Skip("没有收到组播数据（当前环境可能不支持组播回环）")
+		}
+		if !bytes.Equal(buf, ts) {
+			t.Errorf("client %d: RTP头未去除", i)
+		}
+		body.Close()
+	}
+
+	// 所有客户端断开后离开组播组
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		r.mu.Lock()
+		n := len(r.groups)
+		r.mu.Unlock()
+		if n == 0 {
+			return
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	t.Error("客户端断开后没有离开组播组")
+}
@@ -0,0 +1,122 @@
+package server
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// cacheItem 缓存的上游响应
+type cacheItem struct {
+	key         string
+	data        []byte
+	contentType string
+	expires     time.Time
+}
+
+// segmentCache 按字节数限制大小的LRU缓存
+type segmentCache struct {
+	mu       sync.Mutex
+	maxBytes int64
+	size     int64
+	order    *list.List // 最近使用的在前
+	items    map[string]*list.Element
+}
+
+// newSegmentCache 创建缓存，maxBytes为缓存总大小上限
+func newSegmentCache(maxBytes int64) *segmentCache {
+	return &segmentCache{
+		maxBytes: maxBytes,
+		order:    list.New(),
+		items:    make(map[string]*list.Element),
+	}
+}
+
+// get 获取未过期的缓存
+func (c *segmentCache) get(key string) *cacheItem {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.items[key]
+	if !ok {
+		return nil
+	}
+	item := elem.Value.(*cacheItem)
+	if time.Now().After(item.expires) {
+		c.remove(elem)
+		return nil
+	}
+	c.order.MoveToFront(elem)
+	return item
+}
+
+// put 写入缓存，超出上限时淘汰最久未使用的条目
+func (c *segmentCache) put(item *cacheItem) {
+	size := int64(len(item.data))
+	if size > c.maxBytes {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.items[item.key]; ok {
+		c.remove(elem)
+	}
+	c.items[item.key] = c.order.PushFront(item)
+	c.size += size
+
+	for c.size > c.maxBytes {
+		oldest := c.order.Back()
+		if oldest == nil {
+			break
+		}
+		c.remove(oldest)
+	}
+}
+
+// remove 删除条目（调用方持有锁）
+func (c *segmentCache) remove(elem *list.Element) {
+	item := elem.Value.(*cacheItem)
+	c.order.Remove(elem)
+	delete(c.items, item.key)
+	c.size -= int64(len(item.data))
+}
+
+// call 正在进行的上游请求
+type call struct {
+	done chan struct{}
+	item *cacheItem
+	err  error
+}
+
+// flightGroup 合并对同一地址的并发请求，多个观众只产生一次上游请求
+type flightGroup struct {
+	mu    sync.Mutex
+	calls map[string]*call
+}
+
+// do 执行fn，同一key同时只有一个fn在运行，其他调用等待并共享结果
+func (g *flightGroup) do(key string, fn func() (*cacheItem, error)) (*cacheItem, error) {
+	g.mu.Lock()
+	if g.calls == nil {
+		g.calls = make(map[string]*call)
+	}
+	if c, ok := g.calls[key]; ok {
+		g.mu.Unlock()
+		<-c.done
+		return c.item, c.err
+	}
+	c := &call{done: make(chan struct{})}
+	g.calls[key] = c
+	g.mu.Unlock()
+
+	c.item, c.err = fn()
+	close(c.done)
+
+	g.mu.Lock()
+	delete(g.calls, key)
+	g.mu.Unlock()
+
+	return c.item, c.err
+}
@@ -0,0 +1,152 @@
+package server
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"iptv/dto"
+	"iptv/pkg/log"
+	"iptv/pkg/probe"
+)
+
+const (
+	checkTimeout = 3 * time.Second  // 单个源的快速检查超时
+	maxAttempts  = 5                // 每次请求最多检查的源数量
+	goodTTL      = 60 * time.Second // 检查通过的源在此时间内不再重复检查
+)
+
+// goodSource 最近检查通过的源
+type goodSource struct {
+	url     string
+	checked time.Time
+}
+
+var (
+	entriesMu sync.RWMutex
+	entries   = make(map[string]dto.Entry) // 频道名称/tvg-id -> 多源频道
+
+	goodMu sync.Mutex
+	good   = make(map[string]goodSource) // 频道名称 -> 最近可用的源
+)
+
+func init() {
+	mux.HandleFunc("/play/", handlePlay)
+}
+
+// SetEntries 更新可跳转的频道列表（每次任务完成后调用）
+// 同名频道可能出现在多个分组中（例如按源类型分组），它们的源合并到同一个播放地址
+func SetEntries(list []dto.Entry) {
+	index := make(map[string]dto.Entry, len(list)*2)
+	add := func(key string, entry dto.Entry) {
+		if existing, ok := index[key]; ok {
+			entry = mergeSources(existing, entry)
+		}
+		index[key] = entry
+	}
+	for _, entry := range list {
+		if entry.TvgID != "" && entry.TvgID != entry.Name {
+			add(entry.TvgID, entry)
+		}
+		add(entry.Name, entry)
+	}
+
+	entriesMu.Lock()
+	entries = index
+	entriesMu.Unlock()
+
+	goodMu.Lock()
+	good = make(map[string]goodSource)
+	goodMu.Unlock()
+}
+
+// mergeSources 合并两个条目的源，按URL去重后重新排序
+func mergeSources(a, b dto.Entry) dto.Entry {
+	seen := make(map[string]bool, len(a.Sources))
+	sources := make([]dto.Channel, 0, len(a.Sources)+len(b.Sources))
+	for _, source := range append(append([]dto.Channel(nil), a.Sources...), b.Sources...) {
+		if !seen[source.URL] {
+			seen[source.URL] = true
+			sources = append(sources, source)
+		}
+	}
+	dto.SortSources(sources)
+	a.Sources = sources
+	return a
+}
+
+// PlayURL 返回频道的固定播放地址
+func PlayURL(publicURL string, name string) string {
+	return strings.TrimSuffix(publicURL, "/") + "/play/" + url.PathEscape(name)
+}
+
+// handlePlay 按探测结果的顺序选择当前可用的源并302跳转
+func handlePlay(w http.ResponseWriter, r *http.Request) {
+	name, err := url.PathUnescape(strings.TrimPrefix(r.URL.EscapedPath(), "/play/"))
+	if err != nil || name == "" {
+		http.NotFound(w, r)
+		return
+	}
+
+	entriesMu.RLock()
+	entry, ok := entries[name]
+	entriesMu.RUnlock()
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	target := pickSource(entry)
+	if target == "" {
+		log.Warn("频道没有可用的源: %s", entry.Name)
+		http.Error(w, "no available source", http.StatusServiceUnavailable)
+		return
+	}
+
+	// 经过HLS代理播放（客户端无法直连上游时）
+	if proxyEnabled && proxyOpts.Play && (strings.HasPrefix(target, "http://") || strings.HasPrefix(target, "https://")) {
+		target = ProxyURL(target)
+	}
+
+	http.Redirect(w, r, target, http.StatusFound)
+}
+
+// pickSource 同时快速检查前几个源，按排序顺序返回第一个可用的，总耗时不超过单个源的检查超时
+func pickSource(entry dto.Entry) string {
+	goodMu.Lock()
+	cached, ok := good[entry.Name]
+	goodMu.Unlock()
+	if ok && time.Since(cached.checked) < goodTTL {
+		return cached.url
+	}
+
+	sources := entry.Sources
+	if len(sources) > maxAttempts {
+		sources = sources[:maxAttempts]
+	}
+
+	results := make([]chan dto.StreamInfo, len(sources))
+	for i, source := range sources {
+		results[i] = make(chan dto.StreamInfo, 1)
+		go func(url string, result chan<- dto.StreamInfo) {
+			result <- probe.Quick(url, checkTimeout)
+		}(source.URL, results[i])
+	}
+
+	for i, source := range sources {
+		info := <-results[i]
+		if info.IsDead() {
+			log.Debug("源检查失败，尝试下一个: %s %s (%s)", entry.Name, source.URL, info.Reason)
+			continue
+		}
+
+		// udp/rtp等无法检查的源直接使用
+		goodMu.Lock()
+		good[entry.Name] = goodSource{url: source.URL, checked: time.Now()}
+		goodMu.Unlock()
+		return source.URL
+	}
+	return ""
+}
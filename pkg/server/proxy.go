@@ -0,0 +1,297 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	"iptv/pkg/hls"
+	"iptv/pkg/log"
+)
+
+const (
+	proxyPath       = "/proxy"
+	playlistTTL     = time.Second      // 播放列表缓存时间（合并同一时刻的请求）
+	maxCachedObject = 32 << 20         // 单个分片最大缓存32MB，超过的按直播流直接转发
+	upstreamTimeout = 15 * time.Second // 分片/播放列表的上游请求超时
+)
+
+// errTooLarge 上游响应过大（通常是连续的直播流），不适合缓存
+var errTooLarge = errors.New("响应过大")
+
+// ProxyOptions HLS代理参数
+type ProxyOptions struct {
+	CacheBytes int64         // 分片缓存大小
+	SegmentTTL time.Duration // 分片缓存时间
+	Play       bool          // /play 跳转时是否经过代理
+}
+
+var (
+	proxyEnabled bool
+	proxyOpts    ProxyOptions
+	cache        *segmentCache
+	flights      flightGroup
+
+	// 缓存对象的读取超时在请求中设置，直播流转发不设超时（随客户端断开结束）
+	upstreamClient = &http.Client{}
+	streamClient   = &http.Client{}
+
+	// signKey 代理地址的签名密钥，每次启动随机生成；只转发本服务生成的地址，避免被当作开放代理访问内网
+	signKey = newSignKey()
+)
+
+// newSignKey 生成随机签名密钥
+func newSignKey() []byte {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		panic(err)
+	}
+	return key
+}
+
+// sign 上游地址的签名
+func sign(target string) string {
+	mac := hmac.New(sha256.New, signKey)
+	mac.Write([]byte(target))
+	return hex.EncodeToString(mac.Sum(nil)[:16])
+}
+
+// EnableProxy 启用HLS反向代理：/proxy?url=上游地址
+func EnableProxy(opts ProxyOptions) {
+	if opts.CacheBytes <= 0 {
+		opts.CacheBytes = 64 << 20
+	}
+	if opts.SegmentTTL <= 0 {
+		opts.SegmentTTL = time.Minute
+	}
+
+	proxyOpts = opts
+	cache = newSegmentCache(opts.CacheBytes)
+	if !proxyEnabled {
+		mux.HandleFunc(proxyPath, handleProxy)
+	}
+	proxyEnabled = true
+}
+
+// ProxyURL 返回经过代理的地址（相对路径），带上签名
+func ProxyURL(target string) string {
+	return proxyPath + "?url=" + url.QueryEscape(target) + "&sig=" + sign(target)
+}
+
+// handleProxy 转发播放列表（改写其中的地址）和分片（带缓存），只接受本服务签名的地址
+func handleProxy(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	target := query.Get("url")
+	parsed, err := url.Parse(target)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		http.Error(w, "invalid url", http.StatusBadRequest)
+		return
+	}
+	if !hmac.Equal([]byte(query.Get("sig")), []byte(sign(target))) {
+		http.Error(w, "invalid signature", http.StatusForbidden)
+		return
+	}
+
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	if r.Method == http.MethodOptions {
+		w.Header().Set("Access-Control-Allow-Methods", "GET, HEAD, OPTIONS")
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	// 发起上游请求的观众遇到直播流时直接使用该响应转发
+	var stream *http.Response
+	item := cache.get(target)
+	if item == nil {
+		item, err = flights.do(target, func() (*cacheItem, error) {
+			fetched, resp, err := fetchUpstream(r.Context(), target, r.Header.Get("User-Agent"))
+			stream = resp
+			return fetched, err
+		})
+	}
+
+	switch {
+	case stream != nil:
+		defer stream.Body.Close()
+		copyStream(w, stream)
+		return
+	case errors.Is(err, errTooLarge):
+		// 同时等待的其他观众各自请求直播流
+		relayStream(w, r, target)
+		return
+	case err != nil:
+		log.Warn("代理请求失败: %s (%v)", target, err)
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+
+	w.Header().Set("Content-Type", item.contentType)
+	w.Header().Set("Content-Length", fmt.Sprint(len(item.data)))
+	if r.Method != http.MethodHead {
+		_, _ = w.Write(item.data)
+	}
+}
+
+// fetchUpstream 请求上游并写入缓存，播放列表会改写为经过代理的地址
+// 响应是连续的直播流时返回errTooLarge和未关闭的响应（已读取的部分仍在响应体中），随clientCtx结束
+func fetchUpstream(clientCtx context.Context, target string, userAgent string) (*cacheItem, *http.Response, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	timer := time.AfterFunc(upstreamTimeout, cancel)
+	fail := func(err error) (*cacheItem, *http.Response, error) {
+		timer.Stop()
+		cancel()
+		return nil, nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return fail(err)
+	}
+	if userAgent != "" {
+		req.Header.Set("User-Agent", userAgent)
+	}
+
+	resp, err := upstreamClient.Do(req)
+	if err != nil {
+		return fail(err)
+	}
+
+	// 直播流不缓存，转发给发起请求的观众
+	stream := func(read []byte) (*cacheItem, *http.Response, error) {
+		timer.Stop()
+		stop := context.AfterFunc(clientCtx, cancel)
+		resp.Body = &streamBody{
+			Reader: io.MultiReader(bytes.NewReader(read), resp.Body),
+			body:   resp.Body,
+			done:   func() { stop(); cancel() },
+		}
+		return nil, resp, errTooLarge
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return fail(fmt.Errorf("上游返回HTTP %d", resp.StatusCode))
+	}
+	if resp.ContentLength > maxCachedObject {
+		return stream(nil)
+	}
+	// 长度未知且不像播放列表或分片时，按连续直播流处理，避免等待读取
+	contentType := resp.Header.Get("Content-Type")
+	if resp.ContentLength < 0 && !isPlaylist(resp.Request.URL.Path, contentType, nil) && !isSegmentPath(resp.Request.URL.Path) {
+		return stream(nil)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxCachedObject+1))
+	if err != nil {
+		resp.Body.Close()
+		return fail(err)
+	}
+	if len(data) > maxCachedObject {
+		return stream(data)
+	}
+	resp.Body.Close()
+	timer.Stop()
+	cancel()
+
+	item := &cacheItem{
+		key:         target,
+		data:        data,
+		contentType: contentType,
+		expires:     time.Now().Add(proxyOpts.SegmentTTL),
+	}
+
+	// 重定向后以最终地址作为相对路径的基准
+	if isPlaylist(resp.Request.URL.Path, item.contentType, data) {
+		item.data = hls.Rewrite(data, resp.Request.URL.String(), ProxyURL)
+		item.contentType = "application/vnd.apple.mpegurl"
+		item.expires = time.Now().Add(playlistTTL)
+	} else if item.contentType == "" {
+		item.contentType = "video/mp2t"
+	}
+
+	cache.put(item)
+	return item, nil, nil
+}
+
+// streamBody 直播流的响应体，关闭时结束上游请求
+type streamBody struct {
+	io.Reader
+	body io.Closer
+	done func()
+}
+
+// Close 关闭上游响应
+func (b *streamBody) Close() error {
+	err := b.body.Close()
+	b.done()
+	return err
+}
+
+// isPlaylist 判断上游响应是否为m3u8播放列表
+func isPlaylist(urlPath string, contentType string, data []byte) bool {
+	if strings.Contains(strings.ToLower(contentType), "mpegurl") {
+		return true
+	}
+	if strings.EqualFold(path.Ext(urlPath), ".m3u8") {
+		return true
+	}
+	return hls.IsPlaylist(data)
+}
+
+// isSegmentPath 是否为常见的HLS分片/密钥扩展名
+func isSegmentPath(urlPath string) bool {
+	switch strings.ToLower(path.Ext(urlPath)) {
+	case ".ts", ".m4s", ".mp4", ".m4a", ".aac", ".key", ".vtt", ".webvtt":
+		return true
+	}
+	return false
+}
+
+// relayStream 请求并直接转发连续的直播流（不缓存）
+func relayStream(w http.ResponseWriter, r *http.Request, target string) {
+	req, err := http.NewRequestWithContext(r.Context(), http.MethodGet, target, nil)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	req.Header.Set("User-Agent", r.Header.Get("User-Agent"))
+
+	resp, err := streamClient.Do(req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	defer resp.Body.Close()
+	copyStream(w, resp)
+}
+
+// copyStream 把上游响应边读边发给客户端
+func copyStream(w http.ResponseWriter, resp *http.Response) {
+	w.Header().Set("Content-Type", resp.Header.Get("Content-Type"))
+	w.WriteHeader(resp.StatusCode)
+	_, _ = io.Copy(flushWriter{w}, resp.Body)
+}
+
+// flushWriter 每次写入后立即发送给客户端
+type flushWriter struct {
+	w http.ResponseWriter
+}
+
+func (f flushWriter) Write(p []byte) (int, error) {
+	n, err := f.w.Write(p)
+	if flusher, ok := f.w.(http.Flusher); ok {
+		flusher.Flush()
+	}
+	return n, err
+}
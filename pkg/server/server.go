@@ -0,0 +1,195 @@
+package server
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"iptv/pkg/log"
+)
+
+// playlist 内存中的播放列表
+type playlist struct {
+	data        []byte
+	gzipped     []byte
+	etag        string
+	contentType string
+	modTime     time.Time
+}
+
+var (
+	mu        sync.RWMutex
+	playlists = make(map[string]*playlist) // 访问路径 -> 播放列表
+	mux       = http.NewServeMux()
+)
+
+func init() {
+	mux.HandleFunc("/", handlePlaylist)
+}
+
+// Publish 发布播放列表，urlPath 为访问路径（例如 /iptv.m3u），之后的请求立即返回新内容
+func Publish(urlPath string, data []byte) {
+	p := newPlaylist(urlPath, data, time.Now())
+
+	mu.Lock()
+	playlists[urlPath] = p
+	mu.Unlock()
+}
+
+// LoadFile 从磁盘加载已生成的文件（服务启动时使用，避免首次任务完成前无内容可用）
+func LoadFile(urlPath string, file string) error {
+	info, err := os.Stat(file)
+	if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return err
+	}
+
+	p := newPlaylist(urlPath, data, info.ModTime())
+
+	mu.Lock()
+	if _, exists := playlists[urlPath]; !exists {
+		playlists[urlPath] = p
+	}
+	mu.Unlock()
+	return nil
+}
+
+// Handle 注册其他处理器（例如播放跳转、代理）
+func Handle(pattern string, handler http.Handler) {
+	mux.Handle(pattern, handler)
+}
+
+// Start 监听地址并在后台提供服务
+func Start(addr string) error {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("监听%s失败: %v", addr, err)
+	}
+
+	srv := &http.Server{
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	go func() {
+		err := srv.Serve(listener)
+		if err != nil && err != http.ErrServerClosed {
+			log.Error("HTTP服务异常退出: %v", err)
+		}
+	}()
+
+	return nil
+}
+
+// newPlaylist 生成播放列表及其gzip版本和ETag
+func newPlaylist(urlPath string, data []byte, modTime time.Time) *playlist {
+	sum := sha1.Sum(data)
+
+	var buf bytes.Buffer
+	writer, _ := gzip.NewWriterLevel(&buf, gzip.BestCompression)
+	_, _ = writer.Write(data)
+	_ = writer.Close()
+
+	return &playlist{
+		data:        data,
+		gzipped:     buf.Bytes(),
+		etag:        hex.EncodeToString(sum[:8]),
+		contentType: contentType(urlPath),
+		modTime:     modTime,
+	}
+}
+
+// contentType 根据扩展名返回Content-Type
+func contentType(urlPath string) string {
+	switch strings.ToLower(path.Ext(urlPath)) {
+	case ".m3u", ".m3u8":
+		return "audio/x-mpegurl; charset=utf-8"
+	case ".txt":
+		return "text/plain; charset=utf-8"
+	default:
+		return "application/octet-stream"
+	}
+}
+
+// handlePlaylist 返回播放列表，支持ETag/If-None-Match和gzip
+func handlePlaylist(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	mu.RLock()
+	p := playlists[r.URL.Path]
+	mu.RUnlock()
+	if p == nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	// gzip和原始内容是不同的表示，使用不同的ETag
+	body, etag := p.data, `"`+p.etag+`"`
+	useGzip := acceptsGzip(r)
+	if useGzip {
+		body, etag = p.gzipped, `"`+p.etag+`-gzip"`
+	}
+
+	header := w.Header()
+	header.Set("Content-Type", p.contentType)
+	header.Set("ETag", etag)
+	header.Set("Last-Modified", p.modTime.UTC().Format(http.TimeFormat))
+	header.Set("Cache-Control", "no-cache")
+	header.Set("Vary", "Accept-Encoding")
+
+	if etagMatches(r.Header.Get("If-None-Match"), p.etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	if useGzip {
+		header.Set("Content-Encoding", "gzip")
+	}
+	header.Set("Content-Length", fmt.Sprint(len(body)))
+	w.WriteHeader(http.StatusOK)
+	if r.Method == http.MethodGet {
+		_, _ = w.Write(body)
+	}
+}
+
+// acceptsGzip 客户端是否接受gzip编码
+func acceptsGzip(r *http.Request) bool {
+	for _, part := range strings.Split(r.Header.Get("Accept-Encoding"), ",") {
+		coding, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if !strings.EqualFold(strings.TrimSpace(coding), "gzip") {
+			continue
+		}
+		return strings.ReplaceAll(strings.TrimSpace(params), " ", "") != "q=0"
+	}
+	return false
+}
+
+// etagMatches If-None-Match是否命中当前内容（任一表示的ETag均可）
+func etagMatches(ifNoneMatch string, etag string) bool {
+	if ifNoneMatch == "" {
+		return false
+	}
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		candidate = strings.Trim(candidate, `"`)
+		if candidate == "*" || candidate == etag || candidate == etag+"-gzip" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,231 @@
+package server
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"iptv/dto"
+)
+
+func TestPlaylistETagAndGzip(t *testing.T) {
+	Publish("/iptv.m3u", []byte("#EXTM3U\n"))
+
+	req := httptest.NewRequest(http.MethodGet, "/iptv.m3u", nil)
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK || rec.Body.String() != "#EXTM3U\n" {
+		t.Fatalf("GET = %d %q", rec.Code, rec.Body.String())
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != "audio/x-mpegurl; charset=utf-8" {
+		t.Errorf("Content-Type = %q", ct)
+	}
+	etag := rec.Header().Get("ETag")
+
+	// 内容未变化时返回304
+	req = httptest.NewRequest(http.MethodGet, "/iptv.m3u", nil)
+	req.Header.Set("If-None-Match", etag)
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	if rec.Code != http.StatusNotModified {
+		t.Errorf("If-None-Match = %d, want 304", rec.Code)
+	}
+
+	// gzip
+	req = httptest.NewRequest(http.MethodGet, "/iptv.m3u", nil)
+	req.Header.Set("Accept-Encoding", "gzip, deflate")
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	if rec.Header().Get("Content-Encoding") != "gzip" {
+		t.Fatalf("Content-Encoding = %q", rec.Header().Get("Content-Encoding"))
+	}
+	reader, err := gzip.NewReader(rec.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(reader)
+	if string(body) != "#EXTM3U\n" {
+		t.Errorf("gzip body = %q", body)
+	}
+
+	// 发布新内容后旧ETag失效
+	Publish("/iptv.m3u", []byte("#EXTM3U\n#EXTINF:-1,CCTV1\nhttp://a\n"))
+	req = httptest.NewRequest(http.MethodGet, "/iptv.m3u", nil)
+	req.Header.Set("If-None-Match", etag)
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Errorf("after publish = %d, want 200", rec.Code)
+	}
+}
+
+func TestPlayFailover(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/dead" {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte("stream data"))
+	}))
+	defer upstream.Close()
+
+	SetEntries([]dto.Entry{{
+		Channel: dto.Channel{Name: "CCTV1", TvgID: "CCTV1"},
+		Sources: []dto.Channel{
+			{Name: "CCTV1", URL: upstream.URL + "/dead"},
+			{Name: "CCTV1", URL: upstream.URL + "/live"},
+		},
+	}})
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/play/CCTV1", nil))
+	if rec.Code != http.StatusFound {
+		t.Fatalf("play = %d, want 302", rec.Code)
+	}
+	if location := rec.Header().Get("Location"); location != upstream.URL+"/live" {
+		t.Errorf("Location = %q, want fallback source", location)
+	}
+
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/play/unknown", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("unknown channel = %d, want 404", rec.Code)
+	}
+}
+
+func TestProxyRewriteAndCache(t *testing.T) {
+	var segmentHits int32
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/live/index.m3u8":
+			w.Header().Set("Content-Type", "application/vnd.apple.mpegurl")
+			_, _ = w.Write([]byte("#EXTM3U\n#EXTINF:6,\nseg1.ts\n"))
+		case "/live/seg1.ts":
+			atomic.AddInt32(&segmentHits, 1)
+			time.Sleep(50 * time.Millisecond)
+			_, _ = w.Write([]byte("segment"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer upstream.Close()
+
+	EnableProxy(ProxyOptions{CacheBytes: 1 << 20})
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, ProxyURL(upstream.URL+"/live/index.m3u8"), nil))
+	segmentURL := ProxyURL(upstream.URL + "/live/seg1.ts")
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), segmentURL) {
+		t.Fatalf("playlist = %d %q", rec.Code, rec.Body.String())
+	}
+
+	// 多个观众同时请求同一分片只产生一次上游请求
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, segmentURL, nil))
+			if rec.Body.String() != "segment" {
+				t.Errorf("segment body = %q", rec.Body.String())
+			}
+		}()
+	}
+	wg.Wait()
+
+	if hits := atomic.LoadInt32(&segmentHits); hits != 1 {
+		t.Errorf("upstream segment hits = %d, want 1", hits)
+	}
+}
+
+func TestProxyRejectsUnsignedURL(t *testing.T) {
+	EnableProxy(ProxyOptions{CacheBytes: 1 << 20})
+
+	for _, target := range []string{
+		proxyPath + "?url=" + url.QueryEscape("http://169.254.169.254/latest/meta-data/"),
+		proxyPath + "?url=" + url.QueryEscape("http://127.0.0.1:8080/") + "&sig=" + sign("http://127.0.0.1:9090/"),
+	} {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+		if rec.Code != http.StatusForbidden {
+			t.Errorf("%s: status = %d, want %d", target, rec.Code, http.StatusForbidden)
+		}
+	}
+}
+
+func TestProxyStreamsLiveResponseOnce(t *testing.T) {
+	var hits int32
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		// 长度未知的连续流
+		w.Header().Set("Content-Type", "video/mp2t")
+		for i := 0; i < 3; i++ {
+			_, _ = w.Write([]byte("chunk"))
+			w.(http.Flusher).Flush()
+		}
+	}))
+	defer upstream.Close()
+
+	EnableProxy(ProxyOptions{CacheBytes: 1 << 20})
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, ProxyURL(upstream.URL+"/live/stream"), nil))
+	if rec.Code != http.StatusOK || rec.Body.String() != "chunkchunkchunk" {
+		t.Errorf("stream = %d %q", rec.Code, rec.Body.String())
+	}
+	if n := atomic.LoadInt32(&hits); n != 1 {
+		t.Errorf("upstream hits = %d, want 1", n)
+	}
+}
+
+func TestPlayMergesSameNameAndChecksConcurrently(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, "/slow") {
+			time.Sleep(time.Second)
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte("stream data"))
+	}))
+	defer upstream.Close()
+
+	// 按源类型分组时同名频道出现两次，源合并到同一个播放地址
+	SetEntries([]dto.Entry{
+		{
+			Channel: dto.Channel{Name: "CCTV5", TvgID: "CCTV5", Group: "组播-央视"},
+			Sources: []dto.Channel{
+				{Name: "CCTV5", URL: upstream.URL + "/slow1"},
+				{Name: "CCTV5", URL: upstream.URL + "/slow2"},
+			},
+		},
+		{
+			Channel: dto.Channel{Name: "CCTV5", TvgID: "CCTV5", Group: "酒店-央视"},
+			Sources: []dto.Channel{
+				{Name: "CCTV5", URL: upstream.URL + "/slow3"},
+				{Name: "CCTV5", URL: upstream.URL + "/hotel"},
+			},
+		},
+	})
+
+	start := time.Now()
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/play/CCTV5", nil))
+	if rec.Code != http.StatusFound {
+		t.Fatalf("play = %d, want 302", rec.Code)
+	}
+	if location := rec.Header().Get("Location"); location != upstream.URL+"/hotel" {
+		t.Errorf("Location = %q, want merged source", location)
+	}
+	// 三个失效的源同时检查，总耗时接近单个源
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("pickSource took %v", elapsed)
+	}
+}
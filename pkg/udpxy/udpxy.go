@@ -0,0 +1,91 @@
+package udpxy
+
+import (
+	"net"
+	"net/url"
+	"strings"
+)
+
+// Group 组播组
+type Group struct {
+	Proto string // rtp 或 udp
+	Addr  string // 组播地址:端口，例如 239.3.1.241:8000
+	Host  string // udpxy地址中的服务器（host:port），组播地址为空
+}
+
+// Parse 从组播地址（rtp://239.3.1.241:8000、udp://@239.3.1.241:8000）
+// 或udpxy/msd_lite地址（http://1.2.3.4:4022/rtp/239.3.1.241:8000）中提取组播组
+func Parse(rawURL string) (Group, bool) {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return Group{}, false
+	}
+
+	switch strings.ToLower(parsed.Scheme) {
+	case "rtp", "udp":
+		// @ 会被解析为用户信息，Host中只有组播地址
+		return newGroup(strings.ToLower(parsed.Scheme), "", parsed.Host)
+	case "http", "https":
+		parts := strings.SplitN(strings.TrimPrefix(parsed.Path, "/"), "/", 2)
+		if len(parts) != 2 || (parts[0] != "rtp" && parts[0] != "udp") {
+			return Group{}, false
+		}
+		return newGroup(parts[0], parsed.Host, parts[1])
+	}
+	return Group{}, false
+}
+
+// newGroup 校验组播地址，端口缺省时不是有效的组播组
+func newGroup(proto, host, addr string) (Group, bool) {
+	addr = strings.TrimPrefix(addr, "@")
+	ipStr, port, err := net.SplitHostPort(addr)
+	if err != nil || port == "" {
+		return Group{}, false
+	}
+	ip := net.ParseIP(ipStr)
+	if ip == nil || !ip.IsMulticast() {
+		return Group{}, false
+	}
+	return Group{Proto: proto, Addr: addr, Host: host}, true
+}
+
+// URL 组播地址，例如 rtp://239.3.1.241:8000
+func (g Group) URL() string {
+	return g.Proto + "://" + g.Addr
+}
+
+// HTTPURL 指定udpxy地址下的HTTP地址，例如 http://192.168.1.1:4022/rtp/239.3.1.241:8000
+func (g Group) HTTPURL(base string) string {
+	return strings.TrimRight(base, "/") + "/" + g.Proto + "/" + g.Addr
+}
+
+// Rewriter 将组播地址和udpxy地址改写为指定的udpxy/msd_lite/内置转发地址
+type Rewriter struct {
+	base  string
+	hosts map[string]bool
+}
+
+// NewRewriter 创建改写器，base为空时改写为组播地址（rtp://...）
+// 组播地址总是改写；udpxy地址只改写hosts中列出的服务器（同一组播地址在不同运营商网络中是不同的频道）
+func NewRewriter(base string, hosts []string) *Rewriter {
+	r := &Rewriter{base: base, hosts: make(map[string]bool, len(hosts))}
+	for _, host := range hosts {
+		r.hosts[host] = true
+	}
+	return r
+}
+
+// Rewrite 改写单个地址，不是组播相关的地址返回false
+func (r *Rewriter) Rewrite(rawURL string) (string, bool) {
+	group, ok := Parse(rawURL)
+	if !ok {
+		return rawURL, false
+	}
+	if group.Host != "" && !r.hosts[group.Host] {
+		return rawURL, false
+	}
+	if r.base == "" {
+		return group.URL(), true
+	}
+	return group.HTTPURL(r.base), true
+}
@@ -0,0 +1,71 @@
+package udpxy
+
+import "testing"
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		url  string
+		want Group
+		ok   bool
+	}{
+		{"rtp://239.3.1.241:8000", Group{Proto: "rtp", Addr: "239.3.1.241:8000"}, true},
+		{"udp://@239.3.1.241:8000", Group{Proto: "udp", Addr: "239.3.1.241:8000"}, true},
+		{"http://1.2.3.4:4022/rtp/239.3.1.241:8000", Group{Proto: "rtp", Addr: "239.3.1.241:8000", Host: "1.2.3.4:4022"}, true},
+		{"http://1.2.3.4:7088/udp/@239.3.1.241:8000?fcc=1", Group{Proto: "udp", Addr: "239.3.1.241:8000", Host: "1.2.3.4:7088"}, true},
+		{"http://1.2.3.4:4022/rtp/10.0.0.1:8000", Group{}, false},
+		{"rtp://239.3.1.241", Group{}, false},
+		{"http://example.com/live/cctv1.m3u8", Group{}, false},
+	}
+	for _, tt := range tests {
+		got, ok := Parse(tt.url)
+		if ok != tt.ok || got != tt.want {
+			t.Errorf("Parse(%q) = %+v, %v; want %+v, %v", tt.url, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestRewriter(t *testing.T) {
+	r := NewRewriter("http://192.168.1.1:4022/", []string{"1.2.3.4:4022"})
+	tests := []struct {
+		url  string
+		want string
+		ok   bool
+	}{
+		{"rtp://239.3.1.241:8000", "http://192.168.1.1:4022/rtp/239.3.1.241:8000", true},
+		{"http://1.2.3.4:4022/rtp/239.3.1.241:8000", "http://192.168.1.1:4022/rtp/239.3.1.241:8000", true},
+		{"http://5.6.7.8:4022/rtp/239.3.1.241:8000", "http://5.6.7.8:4022/rtp/239.3.1.241:8000", false},
+		{"http://example.com/live/cctv1.m3u8", "http://example.com/live/cctv1.m3u8", false},
+	}
+	for _, tt := range tests {
+		got, ok := r.Rewrite(tt.url)
+		if ok != tt.ok || got != tt.want {
+			t.Errorf("Rewrite(%q) = %q, %v", tt.url, got, ok)
+		}
+	}
+
+	// 未配置地址时还原为组播地址
+	got, _ := NewRewriter("", []string{"5.6.7.8:4022"}).Rewrite("http://5.6.7.8:4022/udp/239.3.1.241:8000")
+	if got != "udp://239.3.1.241:8000" {
+		t.Errorf("Rewrite to multicast = %q", got)
+	}
+}
+
+func TestRewriterWithoutHosts(t *testing.T) {
+	// 未配置hosts时只改写组播地址，其他网络的udpxy地址保持不变
+	r := NewRewriter("http://192.168.1.1:4022", nil)
+	tests := []struct {
+		url  string
+		want string
+		ok   bool
+	}{
+		{"rtp://239.3.1.241:8000", "http://192.168.1.1:4022/rtp/239.3.1.241:8000", true},
+		{"udp://@239.3.1.241:8000", "http://192.168.1.1:4022/udp/239.3.1.241:8000", true},
+		{"http://1.2.3.4:4022/rtp/239.3.1.241:8000", "http://1.2.3.4:4022/rtp/239.3.1.241:8000", false},
+	}
+	for _, tt := range tests {
+		got, ok := r.Rewrite(tt.url)
+		if ok != tt.ok || got != tt.want {
+			t.Errorf("Rewrite(%q) = %q, %v", tt.url, got, ok)
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"iptv/dto"
+	"iptv/pkg/config"
+	"iptv/pkg/log"
+	"iptv/pkg/relay"
+	"iptv/pkg/server"
+)
+
+// startServer 启动内置HTTP服务，并先加载磁盘上已有的输出文件
+func startServer(cfg *config.Config) error {
+	err := checkRoutes(cfg)
+	if err != nil {
+		return err
+	}
+
+	for _, file := range playlistFiles(cfg) {
+		err := server.LoadFile(playlistRoute(file), file)
+		if err != nil {
+			log.Debug("加载已有输出文件失败: %v", err)
+		}
+	}
+
+	listen := ":8080"
+	if cfg.Server.Listen != "" {
+		listen = cfg.Server.Listen
+	}
+
+	// HLS反向代理
+	if cfg.Server.Proxy.Enable {
+		server.EnableProxy(server.ProxyOptions{
+			CacheBytes: int64(cfg.Server.Proxy.CacheSize) << 20,
+			SegmentTTL: time.Duration(cfg.Server.Proxy.SegmentTTL) * time.Second,
+			Play:       cfg.Server.Proxy.Play,
+		})
+		log.Info("HLS代理已启用: %s/proxy?url=", listen)
+	}
+
+	// 组播转HTTP（兼容udpxy）
+	if cfg.Server.Relay.Enable {
+		r, err := relay.New(cfg.Server.Relay.Interface)
+		if err != nil {
+			return err
+		}
+		server.Handle("/udp/", r)
+		server.Handle("/rtp/", r)
+		log.Info("组播转发已启用: %s/rtp/组播地址:端口", listen)
+	}
+
+	err = server.Start(listen)
+	if err != nil {
+		return err
+	}
+
+	log.Info("HTTP服务已启动: %s", listen)
+	return nil
+}
+
+// playlistFiles 发布到HTTP服务的输出文件
+func playlistFiles(cfg *config.Config) []string {
+	var files []string
+	for _, file := range []string{cfg.Output.M3U, cfg.Output.Local, cfg.Output.DIYP, cfg.Output.Home.M3U, cfg.Output.Home.TXT} {
+		if file != "" {
+			files = append(files, file)
+		}
+	}
+	return files
+}
+
+// playlistRoute 输出文件在HTTP服务中的访问路径（文件名）
+func playlistRoute(file string) string {
+	return "/" + filepath.Base(file)
+}
+
+// checkRoutes 检查输出文件的访问路径是否重复，不同目录下的同名文件会互相覆盖（例如家庭和公网的 iptv.m3u）
+func checkRoutes(cfg *config.Config) error {
+	routes := map[string]string{
+		"/play.m3u": "固定播放地址列表",
+		"/play.txt": "固定播放地址列表",
+	}
+	for _, file := range playlistFiles(cfg) {
+		route := playlistRoute(file)
+		if other, ok := routes[route]; ok {
+			return fmt.Errorf("输出文件访问路径重复: %s 和 %s 都对应 %s，请使用不同的文件名", other, file, route)
+		}
+		routes[route] = file
+	}
+	return nil
+}
+
+// publishPlayPlaylists 发布使用固定播放地址（/play/频道名）的播放列表，上游源变化时列表内容不变
+func publishPlayPlaylists(cfg *config.Config, entries []dto.Entry) {
+	playEntries := make([]dto.Entry, len(entries))
+	for i, entry := range entries {
+		// 名称可能带有清晰度标签，优先使用tvg-id（标准名称）
+		key := entry.TvgID
+		if key == "" {
+			key = entry.Name
+		}
+		source := entry.Channel
+		source.URL = server.PlayURL(cfg.Server.PublicURL, key)
+		entry.Sources = []dto.Channel{source}
+		playEntries[i] = entry
+	}
+
+	m3u := dto.ConvertToM3U(playEntries, dto.M3UOptions{TvgURL: cfg.M3U.TvgURL})
+	server.Publish("/play.m3u", []byte(m3u))
+	txt := dto.ConvertToCSV(playEntries, dto.AlternatesFirst)
+	server.Publish("/play.txt", []byte(txt))
+}
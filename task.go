@@ -1,77 +1,106 @@
 package main
 
 import (
-	"bufio"
+	"errors"
+	"fmt"
 	"io"
 	"iptv/dto"
 	"iptv/pkg/bark"
+	"iptv/pkg/category"
 	"iptv/pkg/config"
+	httppkg "iptv/pkg/http"
 	"iptv/pkg/log"
+	"iptv/pkg/normalize"
+	"iptv/pkg/probe"
+	"iptv/pkg/provider"
+	"iptv/pkg/server"
+	"iptv/pkg/udpxy"
+	"net/url"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 )
 
+// sourceTask 待抓取的来源地址
+type sourceTask struct {
+	provider provider.Provider
+	url      string
+}
+
 // channelResult 频道获取结果
 type channelResult struct {
 	index    int
 	url      string
 	channels []dto.Channel
 	err      error
+	skipped  bool // 遇到验证页面后未请求
 }
 
-// runTask 执行主任务
-func runTask(cfg *config.Config) {
+// runTask 执行主任务，没有可输出的频道时返回错误
+func runTask(cfg *config.Config) error {
 	log.Info("============================================================")
 	log.Info("开始执行IPTV频道汇总任务")
 	_ = bark.Push("IPTV", "开始执行IPTV频道汇总任务")
 	log.Info("============================================================")
 
-	// 1. 更新组播源列表（从iptvmulticast.php获取，数量从配置读取）
-	log.Info("[步骤1] 更新组播源列表...")
-	if cfg.MulticastIP.Enable {
-		sources, err := FetchMulticastIPs(cfg.Cookie.Data)
+	// 1. 发现频道来源
+	log.Info("[步骤1] 发现频道来源...")
+	var tasks []sourceTask
+	fetchErrors := make(map[httppkg.ErrorKind]int) // 按错误类型统计的失败次数
+	for _, p := range loadProviders(cfg) {
+		urls, err := p.Discover()
+		if cookieExpired(p, err) {
+			return stopOnChallenge(p.Name(), "", err)
+		}
 		if err != nil {
-			log.Warn("获取组播源失败: %v", err)
-			_ = bark.Push("IPTV", "获取组播源失败: %v", err.Error())
-			log.Info("将使用config/source.txt中的现有URL")
-		} else {
-			log.Info("成功获取 %d 个组播源IP", len(sources))
-			_ = bark.Push("IPTV", "成功获取 %d 个组播源IP", len(sources))
-			err = UpdateSourceFile(sources, "config")
-			if err != nil {
-				log.Warn("更新source.txt失败: %v", err)
-			} else {
-				log.Info("已更新config/source.txt")
-			}
+			fetchErrors[httppkg.KindOf(err)]++
+			log.Warn("来源%s发现地址失败: %v", p.Name(), err)
+			_ = bark.Push("IPTV", "来源%s发现地址失败: %v", p.Name(), err.Error())
+			continue
 		}
-	} else {
-		_ = bark.Push("IPTV", "获取组播源关闭，跳过")
-	}
 
-	// 2. 读取URL列表
-	log.Info("[步骤2] 读取URL列表...")
-	urls, err := readURLsFromFile("config/source.txt")
-	if err != nil {
-		log.Error("读取【config/source.txt】配置文件失败: %v", err)
-		_ = bark.Push("IPTV", "读取【config/source.txt】配置文件失败: %v", err.Error())
-		os.Exit(1)
+		log.Info("来源%s: %d 个地址", p.Name(), len(urls))
+		for _, u := range urls {
+			tasks = append(tasks, sourceTask{provider: p, url: u})
+		}
 	}
 
-	if len(urls) == 0 {
-		log.Error("配置文件中没有找到URL")
-		_ = bark.Push("IPTV", "配置文件中没有找到URL")
-		os.Exit(1)
+	if len(tasks) == 0 {
+		log.Error("没有找到需要抓取的地址")
+		_ = bark.Push("IPTV", "没有找到需要抓取的地址")
+		return errors.New("没有找到需要抓取的地址")
 	}
 
-	log.Info("从配置文件读取到 %d 个URL", len(urls))
+	log.Info("共 %d 个地址", len(tasks))
 
-	// 3. 获取所有频道并汇总（使用并发）
-	log.Info("[步骤3] 获取频道数据...")
+	// 2. 获取所有频道并汇总（使用并发）
+	log.Info("[步骤2] 获取频道数据...")
 	var allChannels []dto.Channel
-	channelMap := make(map[string]bool) // 用于去重
-	var channelMapMutex sync.Mutex      // 用于保护channelMap的互斥锁
+	channelMap := make(map[string]int) // 用于去重：URL -> allChannels中的下标
+	var channelMapMutex sync.Mutex     // 用于保护channelMap的互斥锁
+
+	// 频道名称规范化（在去重和输出之前）
+	var err error
+	var normalizer *normalize.Normalizer
+	if cfg.Normalize.Enable {
+		normalizer, err = normalize.New(cfg.Normalize.AliasFile)
+		if err != nil {
+			log.Warn("加载频道别名失败，仅使用内置别名表: %v", err)
+		}
+	}
+
+	// 频道分组
+	var classifier *category.Classifier
+	if cfg.Category.Enable {
+		classifier, err = category.New(cfg.Category.Rules, cfg.Category.Order, cfg.Category.Fallback)
+		if err != nil {
+			log.Warn("加载分组规则失败，不进行分组: %v", err)
+		}
+	}
 
 	// 获取最大并发数（从配置读取，默认5）
 	maxWorkers := 5
@@ -81,64 +110,167 @@ func runTask(cfg *config.Config) {
 
 	// 创建带缓冲的channel用于控制并发
 	workerChan := make(chan struct{}, maxWorkers)
-	resultsChan := make(chan channelResult, len(urls))
+	resultsChan := make(chan channelResult, len(tasks))
+
+	// 遇到验证页面后不再发起新的请求
+	var challenged atomic.Bool
 
 	// 启动goroutine处理每个URL
-	for i, pageURL := range urls {
+	for i, task := range tasks {
 		workerChan <- struct{}{} // 获取worker
-		go func(index int, url string) {
+		go func(index int, task sourceTask) {
 			defer func() { <-workerChan }() // 释放worker
 
-			log.Info("[%d/%d] 正在处理: %s", index+1, len(urls), url)
-			channels, err := FetchChannelsFromURL(url, cfg.Cookie.Data)
+			if challenged.Load() {
+				resultsChan <- channelResult{index: index, url: task.url, skipped: true}
+				return
+			}
+
+			log.Info("[%d/%d] 正在处理(%s): %s", index+1, len(tasks), task.provider.Name(), task.url)
+			channels, err := task.provider.Fetch(task.url)
+			if cookieExpired(task.provider, err) {
+				challenged.Store(true)
+			}
 
 			resultsChan <- channelResult{
 				index:    index,
-				url:      url,
+				url:      task.url,
 				channels: channels,
 				err:      err,
 			}
-		}(i, pageURL)
+		}(i, task)
 	}
 
 	// 收集结果
 	successCount := 0
-	for i := 0; i < len(urls); i++ {
+	skippedCount := 0            // 遇到验证页面后未请求的地址
+	var challenge *channelResult // 第一个cookie过期的结果
+	for i := 0; i < len(tasks); i++ {
 		result := <-resultsChan
+		if result.skipped {
+			skippedCount++
+			continue
+		}
+		name := tasks[result.index].provider.Name()
+		if cookieExpired(tasks[result.index].provider, result.err) {
+			fetchErrors[httppkg.KindChallenge]++
+			if challenge == nil {
+				challenge = &result
+			}
+			continue
+		}
 		if result.err != nil {
-			log.Warn("获取频道数据失败: %s,URL:%s", result.err.Error(), result.url)
-			_ = bark.Push("IPTV", "获取频道数据失败: %s,URL:%s", result.err.Error(), result.url)
+			fetchErrors[httppkg.KindOf(result.err)]++
+			log.Warn("来源%s获取频道数据失败(%s): %s,URL:%s", name, httppkg.KindOf(result.err).Label(), result.err.Error(), result.url)
+			_ = bark.Push("IPTV", "来源%s获取频道数据失败: %s,URL:%s", name, result.err.Error(), result.url)
 			continue
 		}
 
 		// 去重并添加到汇总列表（需要加锁保护）
 		channelMapMutex.Lock()
 		for _, ch := range result.channels {
-			if !channelMap[ch.URL] {
-				channelMap[ch.URL] = true
+			ch.RawName = ch.Name
+			if normalizer != nil {
+				ch.Name = normalizer.Name(ch.Name)
+			}
+			// 导入的播放列表自带分组时保留
+			if classifier != nil && ch.Group == "" {
+				ch.Group = classifier.Group(ch.Name)
+			}
+			// 同一URL出现在多个来源时保留优先级高的
+			if i, ok := channelMap[ch.URL]; !ok {
+				channelMap[ch.URL] = len(allChannels)
 				allChannels = append(allChannels, ch)
+			} else if ch.Priority > allChannels[i].Priority {
+				allChannels[i] = ch
 			}
 		}
 		currentCount := len(allChannels)
 		channelMapMutex.Unlock()
 
 		successCount++
-		log.Info("成功获取 %d 个频道（累计: %d 个唯一频道）", len(result.channels), currentCount)
-		_ = bark.Push("IPTV", "成功获取 %d 个频道（累计: %d 个唯一频道）", len(result.channels), currentCount)
+		log.Info("成功获取 %d 个频道（累计: %d 个唯一源）", len(result.channels), currentCount)
+		_ = bark.Push("IPTV", "成功获取 %d 个频道（累计: %d 个唯一源）", len(result.channels), currentCount)
+	}
+
+	// 失败包括发现地址失败，按错误类型统计
+	fetchSummary := fmt.Sprintf("抓取完成: 成功 %d", successCount)
+	if failed := errorCount(fetchErrors); failed > 0 {
+		fetchSummary += fmt.Sprintf(", 失败 %d（%s）", failed, errorSummary(fetchErrors))
+	}
+	if skippedCount > 0 {
+		fetchSummary += fmt.Sprintf(", 跳过 %d", skippedCount)
+	}
+	log.Info("%s", fetchSummary)
+	// Cookie过期时只推送一次过期提醒，不再单独推送统计
+	if errorCount(fetchErrors) > 0 && challenge == nil {
+		_ = bark.Push("IPTV", "%s", fetchSummary)
+	}
+
+	// 部分结果不完整，不覆盖上一次的输出
+	if challenge != nil {
+		return stopOnChallenge(tasks[challenge.index].provider.Name(), challenge.url, challenge.err)
 	}
 
 	if len(allChannels) == 0 {
 		log.Error("未找到任何频道数据，请检查cookies是否有效")
 		_ = bark.Push("IPTV", "未找到任何频道数据，请检查cookies是否有效")
-		os.Exit(1)
+		return errors.New("未找到任何频道数据")
+	}
+
+	if normalizer != nil {
+		logRenames(allChannels)
+	}
+
+	// 3. 探测频道可用性（如果启用）
+	var summary probeSummary
+	if cfg.Probe.Enable {
+		log.Info("[步骤3] 探测频道可用性...")
+		allChannels, summary = probeChannels(cfg, allChannels)
+		log.Info("探测完成: 可用 %d, 失效 %d, 超时 %d, 未探测 %d, 编码排除 %d", summary.alive, summary.dead, summary.timeout, summary.skipped, summary.filtered)
+
+		if len(allChannels) == 0 {
+			log.Error("探测后没有可用的频道")
+			_ = bark.Push("IPTV", "探测后没有可用的频道")
+			return errors.New("探测后没有可用的频道")
+		}
 	}
 
 	// 4. 输出结果
 	log.Info("[步骤4] 输出结果...")
 
+	// 按源类型过滤
+	outputChannels := dto.FilterKinds(allChannels, cfg.Output.Kinds)
+	if len(outputChannels) == 0 {
+		log.Error("按源类型过滤后没有可输出的频道")
+		_ = bark.Push("IPTV", "按源类型过滤后没有可输出的频道")
+		return errors.New("按源类型过滤后没有可输出的频道")
+	}
+	if len(outputChannels) < len(allChannels) {
+		log.Info("按源类型过滤后剩余 %d 个源（共 %d 个）", len(outputChannels), len(allChannels))
+	}
+
+	// 按标准名称合并为多源频道，源按健康度和质量排序
+	var entries []dto.Entry
+	if cfg.Output.ByKind {
+		entries = groupByKind(outputChannels, classifier)
+	} else {
+		entries = groupEntries(outputChannels, classifier)
+	}
+
+	entries = fillM3UAttrs(cfg, entries)
+
+	outputEntries := entries
+	if cfg.Output.QualityTag {
+		outputEntries = labelQuality(entries)
+	}
+
 	// 输出M3U格式
 	m3uPath := cfg.Output.M3U
-	err = AggregateChannelsToM3U(allChannels, m3uPath)
+	err = AggregateChannelsToM3U(outputEntries, m3uPath, dto.M3UOptions{
+		TvgURL:     cfg.M3U.TvgURL,
+		Alternates: cfg.Output.Alternates.M3U,
+	})
 	if err != nil {
 		log.Error("输出M3U文件失败: %v", err)
 		_ = bark.Push("IPTV", "输出M3U文件失败: %v", err.Error())
@@ -148,7 +280,7 @@ func runTask(cfg *config.Config) {
 
 	// 输出TXT格式
 	txtPath := cfg.Output.Local
-	err = AggregateChannelsToTXT(allChannels, txtPath)
+	err = AggregateChannelsToTXT(outputEntries, txtPath, cfg.Output.Alternates.TXT)
 	if err != nil {
 		log.Error("输出TXT文件失败: %v", err)
 		_ = bark.Push("IPTV", "输出TXT文件失败: %v", err.Error())
@@ -156,8 +288,33 @@ func runTask(cfg *config.Config) {
 		log.Info("CSV格式: %s", txtPath)
 	}
 
-	log.Info("成功汇总 %d 个唯一频道", len(allChannels))
-	_ = bark.Push("IPTV", "成功汇总 %d 个唯一频道", len(allChannels))
+	// 输出DIYP/TVBox格式（如果配置）
+	if diypPath := cfg.Output.DIYP; diypPath != "" {
+		err = AggregateChannelsToDIYP(outputEntries, diypPath, cfg.Output.DIYPMaxSources)
+		if err != nil {
+			log.Error("输出DIYP文件失败: %v", err)
+			_ = bark.Push("IPTV", "输出DIYP文件失败: %v", err.Error())
+		} else {
+			log.Info("DIYP格式: %s", diypPath)
+		}
+	}
+
+	// 输出家庭内网播放列表（如果配置）
+	if cfg.Output.Home.M3U != "" || cfg.Output.Home.TXT != "" {
+		writeHomePlaylists(cfg, outputEntries)
+	}
+
+	// 更新HTTP服务的播放跳转数据
+	server.SetEntries(entries)
+	if cfg.Server.PublicURL != "" {
+		publishPlayPlaylists(cfg, outputEntries)
+	}
+
+	log.Info("成功汇总 %d 个频道（%d 个源）", len(entries), dto.SourceCount(entries))
+	_ = bark.Push("IPTV", "成功汇总 %d 个频道（%d 个源）", len(entries), dto.SourceCount(entries))
+	if cfg.Probe.Enable {
+		_ = bark.Push("IPTV", "探测结果: 可用 %d, 失效 %d, 超时 %d, 未探测 %d, 编码排除 %d", summary.alive, summary.dead, summary.timeout, summary.skipped, summary.filtered)
+	}
 
 	// 5. 重定向输出（如果启用）
 	if cfg.RedirectOutput.Enable {
@@ -173,31 +330,280 @@ func runTask(cfg *config.Config) {
 	}
 
 	log.Info("============================================================")
+	return nil
 }
 
-// readURLsFromFile 从文件中读取URL列表
-func readURLsFromFile(filename string) ([]string, error) {
-	file, err := os.Open(filename)
-	if err != nil {
-		return nil, err
+// probeSummary 探测结果统计
+type probeSummary struct {
+	alive    int
+	dead     int
+	timeout  int
+	skipped  int
+	filtered int // 因编码被排除的数量
+}
+
+// excludedCodec 返回频道命中的排除编码，未命中返回空
+func excludedCodec(stream dto.StreamInfo, codecs []string) string {
+	for _, codec := range codecs {
+		if stream.HasCodec(codec) {
+			return codec
+		}
+	}
+	return ""
+}
+
+// groupEntries 按标准名称合并为多源频道，并按分组顺序排列，同组内保持原有顺序
+func groupEntries(channels []dto.Channel, classifier *category.Classifier) []dto.Entry {
+	entries := dto.GroupByName(channels)
+	if classifier != nil {
+		sort.SliceStable(entries, func(i, j int) bool {
+			return classifier.Order(entries[i].Group) < classifier.Order(entries[j].Group)
+		})
 	}
-	defer file.Close()
+	return entries
+}
 
-	var urls []string
-	scanner := bufio.NewScanner(file)
-	for scanner.Scan() {
-		line := strings.TrimSpace(scanner.Text())
-		// 跳过空行和注释行
-		if line != "" && !strings.HasPrefix(line, "#") {
-			urls = append(urls, line)
+// groupByKind 每种源类型单独合并和排序，分组名称加上类型前缀（例如 酒店-央视），类型按首次出现的顺序排列
+func groupByKind(channels []dto.Channel, classifier *category.Classifier) []dto.Entry {
+	var entries []dto.Entry
+	kinds, byKind := dto.SplitKinds(channels)
+	for _, kind := range kinds {
+		for _, entry := range groupEntries(byKind[kind], classifier) {
+			switch label := dto.KindLabel(kind); {
+			case label == "":
+			case entry.Group == "":
+				entry.Group = label
+			default:
+				entry.Group = label + "-" + entry.Group
+			}
+			entries = append(entries, entry)
 		}
 	}
+	return entries
+}
+
+// fillM3UAttrs 补全M3U扩展属性：tvg-id/tvg-name取标准名称，台标和回看使用配置的模板
+// 开启频道号时按输出顺序编号
+func fillM3UAttrs(cfg *config.Config, entries []dto.Entry) []dto.Entry {
+	filled := make([]dto.Entry, len(entries))
+	for i, entry := range entries {
+		ch := entry.Channel
+		if ch.TvgID == "" {
+			ch.TvgID = ch.Name
+		}
+		if ch.TvgName == "" {
+			ch.TvgName = ch.Name
+		}
+		if ch.Logo == "" && cfg.M3U.Logo != "" {
+			ch.Logo = strings.ReplaceAll(cfg.M3U.Logo, "{name}", url.PathEscape(ch.Name))
+		}
+		if ch.Catchup == "" {
+			ch.Catchup = cfg.M3U.Catchup
+		}
+		if ch.CatchupSource == "" {
+			ch.CatchupSource = cfg.M3U.CatchupSource
+		}
+		if cfg.M3U.ChannelNumber && ch.ChNo == 0 {
+			ch.ChNo = i + 1
+		}
+		entry.Channel = ch
+		filled[i] = entry
+	}
+	return filled
+}
+
+// labelQuality 在频道名称后追加最优源的清晰度标签（仅用于输出）
+func labelQuality(entries []dto.Entry) []dto.Entry {
+	labeled := make([]dto.Entry, len(entries))
+	for i, entry := range entries {
+		if quality := entry.Stream.Quality(); quality != "" {
+			entry.Name = fmt.Sprintf("%s [%s]", entry.Name, quality)
+		}
+		labeled[i] = entry
+	}
+	return labeled
+}
+
+// writeHomePlaylists 输出家庭内网使用的播放列表：组播地址和udpxy地址改写为内网的udpxy/msd_lite或内置转发地址
+func writeHomePlaylists(cfg *config.Config, entries []dto.Entry) {
+	base := cfg.Output.Home.Udpxy
+	if base == "" && cfg.Server.Relay.Enable {
+		base = cfg.Server.PublicURL
+	}
+	homeEntries, rewritten := rewriteMulticast(entries, udpxy.NewRewriter(base, cfg.Output.Home.Hosts))
+	log.Info("家庭播放列表改写了 %d 个组播源", rewritten)
+
+	if m3uPath := cfg.Output.Home.M3U; m3uPath != "" {
+		err := AggregateChannelsToM3U(homeEntries, m3uPath, dto.M3UOptions{
+			TvgURL:     cfg.M3U.TvgURL,
+			Alternates: cfg.Output.Alternates.M3U,
+		})
+		if err != nil {
+			log.Error("输出家庭M3U文件失败: %v", err)
+			_ = bark.Push("IPTV", "输出家庭M3U文件失败: %v", err.Error())
+		} else {
+			log.Info("家庭M3U格式: %s", m3uPath)
+		}
+	}
+
+	if txtPath := cfg.Output.Home.TXT; txtPath != "" {
+		err := AggregateChannelsToTXT(homeEntries, txtPath, cfg.Output.Alternates.TXT)
+		if err != nil {
+			log.Error("输出家庭TXT文件失败: %v", err)
+			_ = bark.Push("IPTV", "输出家庭TXT文件失败: %v", err.Error())
+		} else {
+			log.Info("家庭CSV格式: %s", txtPath)
+		}
+	}
+}
+
+// rewriteMulticast 改写所有源中的组播地址，改写后地址相同的源只保留第一个，返回改写的源数量
+func rewriteMulticast(entries []dto.Entry, rewriter *udpxy.Rewriter) ([]dto.Entry, int) {
+	count := 0
+	rewritten := make([]dto.Entry, len(entries))
+	for i, entry := range entries {
+		seen := make(map[string]bool)
+		sources := make([]dto.Channel, 0, len(entry.Sources))
+		for _, source := range entry.Sources {
+			u, ok := rewriter.Rewrite(source.URL)
+			if ok {
+				count++
+				source.URL = u
+			}
+			if !seen[source.URL] {
+				seen[source.URL] = true
+				sources = append(sources, source)
+			}
+		}
+		entry.Sources = sources
+		entry.URL = sources[0].URL
+		rewritten[i] = entry
+	}
+	return rewritten, count
+}
+
+// logRenames 在debug日志中记录原始名称到标准名称的映射（每种写法一次），便于排查别名表
+func logRenames(channels []dto.Channel) {
+	seen := make(map[string]bool)
+	renamed := 0
+	for _, ch := range channels {
+		if ch.RawName == ch.Name || seen[ch.RawName] {
+			continue
+		}
+		seen[ch.RawName] = true
+		renamed++
+		log.Debug("频道名称规范化: %s -> %s", ch.RawName, ch.Name)
+	}
+	log.Info("频道名称规范化: %d 种写法改为标准名称", renamed)
+}
+
+// errorCount 失败总次数
+func errorCount(counts map[httppkg.ErrorKind]int) int {
+	total := 0
+	for _, n := range counts {
+		total += n
+	}
+	return total
+}
+
+// errorSummary 按错误类型输出失败次数，例如 超时 2, HTTP 5xx 1
+func errorSummary(counts map[httppkg.ErrorKind]int) string {
+	var parts []string
+	for _, kind := range httppkg.ErrorKinds {
+		if counts[kind] > 0 {
+			parts = append(parts, fmt.Sprintf("%s %d", kind.Label(), counts[kind]))
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// cookieExpired 使用cookie的来源遇到验证页面（其他来源的验证页面按普通失败处理）
+func cookieExpired(p provider.Provider, err error) bool {
+	return errors.Is(err, httppkg.ErrChallenge) && provider.UsesCookie(p)
+}
+
+// stopOnChallenge 遇到验证页面时停止任务，只发送一次提醒（ERROR日志会推送到Bark，退出前等待推送完成）
+func stopOnChallenge(name, pageURL string, err error) error {
+	if pageURL != "" {
+		log.Error("来源%s的Cookie已过期，请在浏览器中重新通过验证并刷新cookie（%v,URL:%s）", name, err, pageURL)
+	} else {
+		log.Error("来源%s的Cookie已过期，请在浏览器中重新通过验证并刷新cookie（%v）", name, err)
+	}
+	return err
+}
+
+// loadProviders 按配置创建频道来源，未配置providers时使用tonkiang（兼容multicastIP配置）
+func loadProviders(cfg *config.Config) []provider.Provider {
+	configs := cfg.Providers
+	if len(configs) == 0 {
+		configs = []config.ProviderConfig{{
+			Type:     "tonkiang",
+			Discover: cfg.MulticastIP.Enable,
+			Limit:    cfg.MulticastIP.Limit,
+		}}
+	}
 
-	if err := scanner.Err(); err != nil {
-		return nil, err
+	var providers []provider.Provider
+	for _, pc := range configs {
+		p, err := provider.New(pc)
+		if err != nil {
+			log.Warn("创建来源失败: %v", err)
+			_ = bark.Push("IPTV", "创建来源失败: %v", err.Error())
+			continue
+		}
+		providers = append(providers, p)
 	}
+	return providers
+}
+
+// probeChannels 探测所有频道，并按配置丢弃或后置失效的频道
+func probeChannels(cfg *config.Config, channels []dto.Channel) ([]dto.Channel, probeSummary) {
+	timeout := 5
+	if cfg.Probe.Timeout > 0 {
+		timeout = cfg.Probe.Timeout
+	}
+
+	probed := probe.Run(channels, probe.Options{
+		Timeout:    time.Duration(timeout) * time.Second,
+		MaxWorkers: cfg.Probe.MaxWorkers,
+		Segments:   cfg.Probe.Segments,
+		Inspect:    cfg.Probe.Inspect,
+	})
+
+	var summary probeSummary
+	var alive, dead []dto.Channel
+	for _, ch := range probed {
+		switch ch.Stream.Status {
+		case dto.StatusAlive:
+			summary.alive++
+		case dto.StatusDead:
+			summary.dead++
+		case dto.StatusTimeout:
+			summary.timeout++
+		default:
+			summary.skipped++
+		}
 
-	return urls, nil
+		if ch.Stream.IsDead() {
+			log.Debug("频道不可用: %s %s (%s)", ch.Name, ch.URL, ch.Stream.Reason)
+			dead = append(dead, ch)
+			continue
+		}
+
+		// 过滤播放设备不支持的编码（如老机顶盒不支持HEVC）
+		if codec := excludedCodec(ch.Stream, cfg.Probe.ExcludeCodecs); codec != "" {
+			log.Debug("频道编码被排除: %s %s (%s)", ch.Name, ch.URL, codec)
+			summary.filtered++
+			continue
+		}
+		alive = append(alive, ch)
+	}
+
+	// demote: 失效频道排到末尾；默认直接丢弃
+	if cfg.Probe.Action == "demote" {
+		return append(alive, dead...), summary
+	}
+	return alive, summary
 }
 
 // redirectOutput 拷贝解析结果到指定位置
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"iptv/dto"
+	"iptv/pkg/config"
+)
+
+func TestProbeChannelsAction(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/dead.ts" {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.Write(make([]byte, 188))
+	}))
+	defer srv.Close()
+
+	channels := []dto.Channel{
+		{Name: "CCTV1", URL: srv.URL + "/dead.ts"},
+		{Name: "CCTV2", URL: srv.URL + "/live.ts"},
+		{Name: "CCTV3", URL: "rtp://239.1.1.1:5000"},
+	}
+
+	tests := []struct {
+		action string
+		want   []string
+	}{
+		{"", []string{"CCTV2", "CCTV3"}},
+		{"drop", []string{"CCTV2", "CCTV3"}},
+		{"demote", []string{"CCTV2", "CCTV3", "CCTV1"}},
+	}
+	for _, tt := range tests {
+		cfg := &config.Config{}
+		cfg.Probe.Timeout = 2
+		cfg.Probe.Action = tt.action
+
+		got, summary := probeChannels(cfg, channels)
+		if summary.alive != 1 || summary.dead != 1 || summary.skipped != 1 {
+			t.Errorf("%q: summary = %+v", tt.action, summary)
+		}
+		if len(got) != len(tt.want) {
+			t.Fatalf("%q: got %d channels, want %v", tt.action, len(got), tt.want)
+		}
+		for i, ch := range got {
+			if ch.Name != tt.want[i] {
+				t.Errorf("%q: got[%d] = %s, want %s", tt.action, i, ch.Name, tt.want[i])
+			}
+		}
+		if tt.action == "demote" && got[2].Stream.Reason != "HTTP 500" {
+			t.Errorf("demoted Reason = %q", got[2].Stream.Reason)
+		}
+	}
+}
+
+func TestCheckRoutes(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.Output.M3U = "output/iptv.m3u"
+	cfg.Output.Local = "output/local.txt"
+	cfg.Output.Home.M3U = "output/home/home.m3u"
+	if err := checkRoutes(cfg); err != nil {
+		t.Fatalf("checkRoutes: %v", err)
+	}
+
+	// 家庭播放列表与公网播放列表同名时访问路径冲突
+	cfg.Output.Home.M3U = "output/home/iptv.m3u"
+	if err := checkRoutes(cfg); err == nil {
+		t.Error("duplicate basename accepted")
+	}
+
+	cfg.Output.Home.M3U = ""
+	cfg.Output.Home.TXT = "output/play.txt"
+	if err := checkRoutes(cfg); err == nil {
+		t.Error("reserved route accepted")
+	}
+}